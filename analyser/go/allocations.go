@@ -0,0 +1,50 @@
+package analyser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+)
+
+const RuleAllocationInLoop = "alloc-in-loop"
+
+func init() {
+	Register("allocations", func() Analyser { return &AllocationAnalyser{} })
+}
+
+// AllocationAnalyser counts the allocation sites of a function in the "allocations" metric
+// and reports the ones inside loops, which allocate on every iteration
+type AllocationAnalyser struct{}
+
+func (*AllocationAnalyser) Visit(node ast.Node, functionContext *FunctionContext) {
+	var allocation string
+	switch expr := node.(type) {
+	case *ast.CallExpr:
+		if ident, ok := expr.Fun.(*ast.Ident); ok && (ident.Name == "make" || ident.Name == "new") {
+			allocation = ident.Name
+		}
+	case *ast.CompositeLit:
+		switch literalType := expr.Type.(type) {
+		case *ast.ArrayType:
+			if literalType.Len == nil {
+				allocation = "slice literal"
+			}
+		case *ast.MapType:
+			allocation = "map literal"
+		}
+	case *ast.UnaryExpr:
+		if _, ok := expr.X.(*ast.CompositeLit); ok && expr.Op == token.AND {
+			allocation = "&literal"
+		}
+	}
+	if allocation == "" {
+		return
+	}
+
+	functionContext.AddMetric("allocations", 1)
+	if functionContext.InLoop() {
+		functionContext.AddMetric("loopAllocations", 1)
+		loop := functionContext.Loops[len(functionContext.Loops)-1]
+		functionContext.Report(node.Pos(), RuleAllocationInLoop, fmt.Sprintf("%s allocates on every iteration of the loop at line %d, consider hoisting it", allocation, functionContext.FileSet.Position(loop.Pos()).Line))
+	}
+}
@@ -1,200 +1,387 @@
 package analyser
 
 import (
-	"errors"
+	"context"
+	"fmt"
 	"go/ast"
-	"go/parser"
+	"go/format"
 	"go/token"
+	"io"
+	"io/fs"
 	"math"
 	"os"
+	"strings"
 )
 
 type Analyser interface {
 	Visit(node ast.Node, functionContext *FunctionContext)
 }
 
+// ComplexityAnalyser is the name TimeAndSpaceComplexityAnalyser is registered under, it is
+// always enabled and visits every node before the other analysers
+const ComplexityAnalyser = "complexity"
+
+func init() {
+	Register(ComplexityAnalyser, func() Analyser { return &TimeAndSpaceComplexityAnalyser{} })
+}
+
 type TimeAndSpaceComplexityAnalyser struct {
+	CostModel CostModel
+	// Callee resolves the complexity of calls the cost model does not know, like calls
+	// into other packages whose complexity was computed earlier
+	Callee func(call *ast.CallExpr) (Complexity, bool)
+	// bodies are the loops whose body the walk has not left yet, by body
+	bodies map[*ast.BlockStmt]*loopBody
 }
 
-func Analyse(filePath string, functionName string) ([]FunctionInfo, error) {
+// loopBody is a loop as seen from its body, which the loop is entered with so that its init,
+// condition and range expression are counted once. Loops that do not grow with an input are
+// only checked for what their body repeats
+type loopBody struct {
+	loop      ast.Stmt
+	driven    bool
+	factor    Factor
+	variables []string
+	// collection is what the loop may be searching, see checkSearchLoop
+	collection ast.Expr
+	entered    int
+}
+
+// Analyse analyses the functions of a Go file, or only functionName when it is not empty.
+// Cost models give the complexity of calls to code outside of the file
+func Analyse(filePath string, functionName string, costModels ...CostModel) ([]FunctionInfo, error) {
 	src, IOError := os.ReadFile(filePath)
 	if IOError != nil {
 		return nil, IOError
 	}
-	fset := token.NewFileSet()
-	file, IOError := parser.ParseFile(fset, "", src, parser.AllErrors)
-	if IOError != nil {
-		return nil, IOError
-	}
-	// ast.Print(fset, file)
-
-	var funcsInfo []FunctionInfo
-	var fileContext FileContext = GetFileContext(file)
+	return AnalyseSource(filePath, src, functionName, costModels...)
+}
 
-	var functionInfoError error
-	if functionName == "" {
-		funcsInfo, functionInfoError = getAllFunctionInfo(file, &fileContext)
-	} else {
-		var funcInfo FunctionInfo
-		funcInfo, functionInfoError = getFunctionInfoByName(file, &fileContext, functionName)
-		if functionInfoError == nil {
-			funcsInfo = append(funcsInfo, funcInfo)
+// AnalyseSource analyses Go source that is already in memory, filePath is only used for positions.
+// When the source has syntax errors, the functions that parsed are returned with a *SyntaxError
+func AnalyseSource(filePath string, src []byte, functionName string, costModels ...CostModel) ([]FunctionInfo, error) {
+	options := Options{CostModels: costModels}
+	if functionName != "" {
+		options.Functions = []string{functionName}
+	}
+	report, err := RunSource(context.Background(), filePath, src, options)
+	if err != nil {
+		return nil, err
+	}
+	var syntaxDiagnostics []Diagnostic
+	for _, diagnostic := range report.Diagnostics {
+		if diagnostic.Rule == RuleSyntax {
+			syntaxDiagnostics = append(syntaxDiagnostics, diagnostic)
 		}
 	}
+	if len(syntaxDiagnostics) > 0 {
+		return report.Functions, &SyntaxError{Diagnostics: syntaxDiagnostics}
+	}
+	return report.Functions, nil
+}
 
-	return funcsInfo, functionInfoError
+// SyntaxError lists the syntax errors of a source, the functions around them are not analysed
+type SyntaxError struct {
+	Diagnostics []Diagnostic
 }
 
-func getAllFunctionInfo(file *ast.File, fileContext *FileContext) ([]FunctionInfo, error) {
-	var funcs []FunctionInfo
+func (err *SyntaxError) Error() string {
+	first := err.Diagnostics[0]
+	message := fmt.Sprintf("%s:%d: %s", first.File, first.Line, first.Message)
+	if len(err.Diagnostics) > 1 {
+		message += fmt.Sprintf(" (and %d more errors)", len(err.Diagnostics)-1)
+	}
+	return message
+}
 
-	for _, declaration := range file.Decls {
-		switch decl := declaration.(type) {
-		case *ast.FuncDecl:
-			functionContext := GetFunctionContext(decl, fileContext)
-			analyser := &TimeAndSpaceComplexityAnalyser{}
-			for _, stmt := range decl.Body.List {
-				analyser.Visit(stmt, functionContext)
-			}
-			funcs = append(funcs, ParseContextToInfo(functionContext))
-		}
+// AnalyseReader analyses Go source read until EOF, like stdin or an HTTP request body
+func AnalyseReader(filePath string, reader io.Reader, functionName string, costModels ...CostModel) ([]FunctionInfo, error) {
+	src, IOError := io.ReadAll(reader)
+	if IOError != nil {
+		return nil, IOError
 	}
+	return AnalyseSource(filePath, src, functionName, costModels...)
+}
 
-	return funcs, nil
+// AnalyseFS analyses a Go file of a file system, like an embed.FS or a zip archive
+func AnalyseFS(fsys fs.FS, filePath string, functionName string, costModels ...CostModel) ([]FunctionInfo, error) {
+	src, IOError := fs.ReadFile(fsys, filePath)
+	if IOError != nil {
+		return nil, IOError
+	}
+	return AnalyseSource(filePath, src, functionName, costModels...)
 }
 
-func getFunctionInfoByName(file *ast.File, fileContext *FileContext, functionName string) (FunctionInfo, error) {
-	for _, declaration := range file.Decls {
-		switch decl := declaration.(type) {
-		case *ast.FuncDecl:
-			if isFunctionName(decl, functionName) {
-				functionContext := GetFunctionContext(decl, fileContext)
-				analyser := &TimeAndSpaceComplexityAnalyser{}
-				for _, stmt := range decl.Body.List {
-					analyser.Visit(stmt, functionContext)
-				}
-				return ParseContextToInfo(functionContext), nil
-			}
-		}
+// AnalyseFile analyses a file that was already parsed, directives are only read when
+// it was parsed with parser.ParseComments
+func AnalyseFile(fset *token.FileSet, file *ast.File, functionName string, costModels ...CostModel) ([]FunctionInfo, error) {
+	options := Options{CostModels: costModels}
+	if functionName != "" {
+		options.Functions = []string{functionName}
+	}
+	report, err := RunFile(context.Background(), fset, file, options)
+	if err != nil {
+		return nil, err
 	}
-	return FunctionInfo{}, errors.New("no such function in this file")
+	return report.Functions, nil
 }
 
+// Visit counts what a node costs where it stands: calls and allocations at the depth of the
+// loops around them, loops from their body on. Leave leaves the loops again
 func (tscAnalyser *TimeAndSpaceComplexityAnalyser) Visit(node ast.Node, functionContext *FunctionContext) {
 
 	switch stmt := node.(type) {
 	case *ast.AssignStmt:
-		tscAnalyser.Visit(stmt.Rhs[0], functionContext)
-
-	case *ast.BinaryExpr:
-		tscAnalyser.Visit(stmt.X, functionContext)
-		tscAnalyser.Visit(stmt.Y, functionContext)
+		functionContext.checkStringConcat(stmt)
 
 	case *ast.BlockStmt:
-		for _, inner := range stmt.List {
-			tscAnalyser.Visit(inner, functionContext)
+		if body, ok := tscAnalyser.bodies[stmt]; ok {
+			tscAnalyser.enterBody(body, stmt, functionContext)
+		}
+
+	case *ast.BranchStmt:
+		if stmt.Tok == token.GOTO {
+			functionContext.Unresolve(stmt, UnresolvedLoop, "goto %s may repeat code any number of times", stmt.Label.Name)
 		}
 
 	case *ast.CallExpr:
+		functionContext.countCall(stmt)
+		collection, searches := linearSearch(stmt)
+		if searches {
+			functionContext.checkSearchCall(stmt, collection)
+		}
+		if cost, callee, ok := tscAnalyser.callCost(stmt); ok {
+			if callee {
+				functionContext.recordTerm(functionContext.CurrentDepth+cost.Time, functionContext.callFactor(stmt, cost.Time))
+			} else {
+				functionContext.recordTime(functionContext.CurrentDepth+cost.Time, functionContext.callFactor(stmt, cost.Time))
+				functionContext.own.Space = max(functionContext.own.Space, cost.Space)
+			}
+			functionContext.MaxMalloc = float32(math.Max(float64(cost.Space), float64(functionContext.MaxMalloc)))
+			if cost.Time > 0 || cost.Space > 0 {
+				functionContext.addEvidence(stmt, EvidenceCall, functionContext.CurrentDepth+cost.Time, cost.Space, "call to %s costs %s time, %s space", calleeLabel(stmt), FormatComplexity(cost.Time), FormatComplexity(cost.Space))
+			}
+		} else if searches {
+			functionContext.recordTime(functionContext.CurrentDepth+1, functionContext.rangeFactor(collection))
+			functionContext.addEvidence(stmt, EvidenceCall, functionContext.CurrentDepth+1, 0, "%s searches %s linearly, %s", calleeLabel(stmt), nodeString(collection), FormatComplexity(functionContext.CurrentDepth+1))
+		} else if !isKnownCall(stmt, functionContext) {
+			functionContext.Unresolve(stmt, UnresolvedCall, "call to %s has an unknown cost", calleeLabel(stmt))
+		}
+
 		funIdent, ok := stmt.Fun.(*ast.Ident)
 		if !ok {
-			return
+			break
 		}
 
 		switch funIdent.Name {
 		case "make":
+			if len(stmt.Args) == 0 {
+				break
+			}
 			switch stmt.Args[0].(type) {
 			case *ast.ArrayType:
-				if size, ok := stmt.Args[1].(*ast.Ident); ok {
+				if len(stmt.Args) < 2 {
+					break
+				}
+				switch size := stmt.Args[1].(type) {
+				case *ast.BasicLit:
+				case *ast.Ident:
 					if IsParam(size.Name, &functionContext.SymbolTable) {
 						functionContext.CurrentMalloc = 1 + functionContext.CurrentDepth
+						functionContext.addEvidence(stmt, EvidenceAllocation, 0, functionContext.CurrentMalloc, "make allocates %s elements, %s space", size.Name, FormatComplexity(functionContext.CurrentMalloc))
+					} else if !IsBounded(size.Name, &functionContext.SymbolTable) {
+						functionContext.Unresolve(size, UnresolvedSize, "allocation size %s is not a parameter", size.Name)
 					}
+				default:
+					functionContext.Unresolve(size, UnresolvedSize, "allocation size %s is not understood", nodeString(size))
 				}
 			case *ast.MapType:
 				functionContext.CurrentMalloc = 1 + functionContext.CurrentDepth
+				functionContext.addEvidence(stmt, EvidenceAllocation, 0, functionContext.CurrentMalloc, "make allocates a map, %s space", FormatComplexity(functionContext.CurrentMalloc))
 			}
 
 		case "append":
 			functionContext.CurrentMalloc = functionContext.CurrentDepth
+			functionContext.addEvidence(stmt, EvidenceAllocation, 0, functionContext.CurrentMalloc, "append grows a slice in a loop, %s space", FormatComplexity(functionContext.CurrentMalloc))
 
 		case functionContext.Name:
 			time, space := GetRecursiveComplexity(stmt)
+			if time == 0 && space == 0 {
+				functionContext.Unresolve(stmt, UnresolvedRecursion, "recursive call %s does not shrink its first argument in a known way", nodeString(stmt))
+			}
+			factor := Factor{Input: "n", Index: time}
+			if len(stmt.Args) > 0 {
+				if driver, ok := functionContext.driverFactor(stmt.Args[0]); ok {
+					factor = driver
+					factor.Index = time
+				}
+			}
+			functionContext.Inputs = append(functionContext.Inputs, factor)
 			functionContext.CurrentDepth += time
 			functionContext.CurrentMalloc += space
 			functionContext.RecursiveFanOut++
+			functionContext.addEvidence(stmt, EvidenceRecursion, functionContext.CurrentDepth, functionContext.CurrentMalloc, "recursive call %s, %s time", nodeString(stmt), FormatComplexity(functionContext.CurrentDepth))
 		}
 
-	case *ast.CaseClause:
-		for _, inner := range stmt.Body {
-			tscAnalyser.Visit(inner, functionContext)
-		}
-
-	case *ast.ExprStmt:
-		tscAnalyser.Visit(stmt.X, functionContext)
-
 	case *ast.ForStmt:
+		body := &loopBody{loop: stmt, variables: loopVariables(stmt.Init)}
+		tscAnalyser.addBody(stmt.Body, body)
 		condExpr, ok := stmt.Cond.(*ast.BinaryExpr)
 		if !ok {
-			return
+			if stmt.Cond == nil {
+				functionContext.Unresolve(stmt, UnresolvedLoop, "loop without a condition runs until it breaks")
+			} else {
+				functionContext.Unresolve(stmt, UnresolvedLoop, "loop condition %s is not understood", nodeString(stmt.Cond))
+			}
+			break
 		}
 		switch iterator := condExpr.Y.(type) {
 		case *ast.BasicLit:
-			for _, inner := range stmt.Body.List {
-				tscAnalyser.Visit(inner, functionContext)
+			if ExprContainsParam(condExpr.X, &functionContext.SymbolTable) {
+				functionContext.Unresolve(stmt, UnresolvedLoop, "loop condition %s depends on a parameter in an unknown way", nodeString(condExpr))
+			} else if start, ok := loopStart(stmt); ok && ExprContainsParam(start, &functionContext.SymbolTable) {
+				// counting down from an input, like for i := len(items) - 1; i >= 0; i--
+				if _, steps := stmt.Post.(*ast.IncDecStmt); steps {
+					body.driven, body.factor, body.collection = true, functionContext.rangeFactor(start), searchedCollection(start)
+				} else {
+					functionContext.Unresolve(stmt, UnresolvedLoop, "loop from %s steps in an unknown way", nodeString(start))
+				}
 			}
 		case *ast.Ident:
 			if IsParam(iterator.Name, &functionContext.SymbolTable) {
-				functionContext.CurrentDepth++
-				for _, inner := range stmt.Body.List {
-					tscAnalyser.Visit(inner, functionContext)
-				}
-				functionContext.CurrentDepth--
+				body.driven, body.factor, body.collection = true, functionContext.inputFactor(iterator.Name), iterator
+			} else if !IsBounded(iterator.Name, &functionContext.SymbolTable) {
+				functionContext.Unresolve(stmt, UnresolvedLoop, "loop bound %s is not a parameter", iterator.Name)
 			}
-
 		default:
 			if ExprContainsParam(condExpr, &functionContext.SymbolTable) {
-				functionContext.CurrentDepth++
-				for _, inner := range stmt.Body.List {
-					tscAnalyser.Visit(inner, functionContext)
-				}
-				functionContext.CurrentDepth--
-			} else {
-				for _, inner := range stmt.Body.List {
-					tscAnalyser.Visit(inner, functionContext)
-				}
+				body.driven, body.factor, body.collection = true, functionContext.rangeFactor(condExpr), searchedCollection(condExpr.Y)
+			} else if !isConstantBound(condExpr.Y) {
+				functionContext.Unresolve(stmt, UnresolvedLoop, "loop bound %s is not a parameter", nodeString(condExpr.Y))
 			}
 		}
 
-	case *ast.IfStmt:
-		for _, inner := range stmt.Body.List {
-			tscAnalyser.Visit(inner, functionContext)
-		}
-		if stmt.Else != nil {
-			tscAnalyser.Visit(stmt.Else, functionContext)
-		}
-
 	case *ast.LabeledStmt:
-		tscAnalyser.Visit(stmt.Stmt, functionContext)
+		if functionContext.labels == nil {
+			functionContext.labels = map[ast.Stmt]ast.Stmt{}
+		}
+		functionContext.labels[stmt.Stmt] = stmt
 
 	case *ast.RangeStmt:
-		functionContext.CurrentDepth++
-		for _, inner := range stmt.Body.List {
-			tscAnalyser.Visit(inner, functionContext)
+		body := &loopBody{loop: stmt, variables: loopVariables(stmt)}
+		tscAnalyser.addBody(stmt.Body, body)
+		if rangeIdent, ok := stmt.X.(*ast.Ident); !ok || !IsBounded(rangeIdent.Name, &functionContext.SymbolTable) {
+			body.driven, body.factor, body.collection = true, functionContext.rangeFactor(stmt.X), stmt.X
 		}
-		functionContext.CurrentDepth--
+	}
 
-	case *ast.ReturnStmt:
-		for _, inner := range stmt.Results {
-			tscAnalyser.Visit(inner, functionContext)
-		}
+	functionContext.recordTime(functionContext.CurrentDepth)
+	functionContext.MaxMalloc = float32(math.Max(float64(functionContext.CurrentMalloc), float64(functionContext.MaxMalloc)))
+	functionContext.own.Space = max(functionContext.own.Space, functionContext.CurrentMalloc)
 
-	case *ast.SwitchStmt:
-		for _, cases := range stmt.Body.List {
-			tscAnalyser.Visit(cases, functionContext)
+}
+
+// Leave leaves the loop whose body the walk is done with
+func (tscAnalyser *TimeAndSpaceComplexityAnalyser) Leave(node ast.Node, functionContext *FunctionContext) {
+	block, ok := node.(*ast.BlockStmt)
+	if !ok {
+		return
+	}
+	if body, ok := tscAnalyser.bodies[block]; ok {
+		if body.driven {
+			functionContext.exitLoop(body.entered)
 		}
+		delete(tscAnalyser.bodies, block)
 	}
+}
 
-	functionContext.MaxDepth = float32(math.Max(float64(functionContext.CurrentDepth), float64(functionContext.MaxDepth)))
-	functionContext.MaxMalloc = float32(math.Max(float64(functionContext.CurrentMalloc), float64(functionContext.MaxMalloc)))
+func (tscAnalyser *TimeAndSpaceComplexityAnalyser) addBody(block *ast.BlockStmt, body *loopBody) {
+	if tscAnalyser.bodies == nil {
+		tscAnalyser.bodies = map[*ast.BlockStmt]*loopBody{}
+	}
+	tscAnalyser.bodies[block] = body
+}
+
+// enterBody enters a loop driven by an input, which multiplies what its body costs by the
+// factor it entered. What should not be repeated on every iteration is reported first
+func (tscAnalyser *TimeAndSpaceComplexityAnalyser) enterBody(body *loopBody, block *ast.BlockStmt, functionContext *FunctionContext) {
+	if body.driven {
+		body.entered = functionContext.enterLoop(body.loop, body.factor)
+		functionContext.checkSearchLoop(body.loop, block, body.variables, body.collection)
+	}
+	functionContext.checkLoopSetup(body.loop, block)
+	functionContext.checkPrealloc(body.loop, block)
+}
+
+// callCost is the complexity of a call given by the cost model or, when callee is set, by
+// the Callee hook
+func (tscAnalyser *TimeAndSpaceComplexityAnalyser) callCost(call *ast.CallExpr) (cost Complexity, callee bool, ok bool) {
+	if cost, ok := tscAnalyser.CostModel[CalleeName(call)]; ok {
+		return cost, false, true
+	}
+	if tscAnalyser.Callee != nil {
+		cost, ok := tscAnalyser.Callee(call)
+		return cost, ok, ok
+	}
+	return Complexity{}, false, false
+}
+
+// isKnownCall tells whether a call the cost model and callees do not know is still understood:
+// builtins, conversions to basic types and function literals are, and so are recursive calls,
+// which are handled apart
+func isKnownCall(call *ast.CallExpr, functionContext *FunctionContext) bool {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return builtins[fun.Name] || fun.Name == functionContext.Name
+	case *ast.ArrayType, *ast.MapType, *ast.ChanType, *ast.InterfaceType:
+		return true
+	case *ast.FuncLit:
+		// the body of the literal is walked like the rest of the function
+		return true
+	case *ast.ParenExpr:
+		_, ok := fun.X.(*ast.StarExpr)
+		return ok
+	}
+	return false
+}
 
+// loopStart is what the variable of a counting loop starts from, len(items) - 1 in
+// for i := len(items) - 1; i >= 0; i--
+func loopStart(loop *ast.ForStmt) (ast.Expr, bool) {
+	init, ok := loop.Init.(*ast.AssignStmt)
+	if !ok || len(init.Lhs) != 1 || len(init.Rhs) != 1 {
+		return nil, false
+	}
+	return init.Rhs[0], true
+}
+
+// isConstantBound tells whether a loop bound is made of literals only, like 10 or 1<<4
+func isConstantBound(expr ast.Expr) bool {
+	switch exp := expr.(type) {
+	case *ast.BasicLit:
+		return true
+	case *ast.BinaryExpr:
+		return isConstantBound(exp.X) && isConstantBound(exp.Y)
+	case *ast.ParenExpr:
+		return isConstantBound(exp.X)
+	case *ast.UnaryExpr:
+		return isConstantBound(exp.X)
+	}
+	return false
+}
+
+func calleeLabel(call *ast.CallExpr) string {
+	if name := CalleeName(call); name != "" {
+		return name
+	}
+	return nodeString(call.Fun)
+}
+
+// nodeString prints a node back as source for messages
+func nodeString(node ast.Node) string {
+	var source strings.Builder
+	if err := format.Node(&source, token.NewFileSet(), node); err != nil {
+		return "?"
+	}
+	return source.String()
 }
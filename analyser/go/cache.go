@@ -0,0 +1,140 @@
+package analyser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Version is bumped whenever a change of the analyser changes its results, so that results
+// cached by an older version are not reused
+const Version = 16
+
+// Cache keeps the results of functions between runs, see package cache for one on disk.
+// It is used by the workers of a run concurrently
+type Cache interface {
+	Get(key string) (FunctionInfo, bool)
+	Put(key string, functionInfo FunctionInfo)
+}
+
+// optionsKey identifies the options of a run that change the results of functions in cache keys
+func optionsKey(costModel CostModel, analysers []string) string {
+	// encoding/json sorts map keys, so the same models always give the same key
+	src, _ := json.Marshal(costModel)
+	sum := sha256.Sum256(fmt.Appendf(src, "\nanalysers %q", analysers))
+	return hex.EncodeToString(sum[:])
+}
+
+// typesKeys identify the declarations every package of the files type checks with: its
+// imports, types, constants, variables and function signatures, whatever file they are in.
+// Function bodies are left out, they do not change the types of other functions
+func typesKeys(files []parsedFile) map[string]string {
+	declarations := map[string][]string{}
+	for _, file := range files {
+		if file.file == nil {
+			continue
+		}
+		key := packageKey(file.fileContext)
+		for _, declaration := range file.file.Decls {
+			var node ast.Node = declaration
+			if decl, ok := declaration.(*ast.FuncDecl); ok {
+				node = &ast.FuncDecl{Recv: decl.Recv, Name: decl.Name, Type: decl.Type}
+			}
+			var source strings.Builder
+			if err := format.Node(&source, file.fileContext.FileSet, node); err == nil {
+				declarations[key] = append(declarations[key], source.String())
+			}
+		}
+	}
+	keys := map[string]string{}
+	for key, sources := range declarations {
+		// sorted, so that moving a declaration to another file keeps the key
+		sort.Strings(sources)
+		sum := sha256.Sum256([]byte(strings.Join(sources, "\x00")))
+		keys[key] = hex.EncodeToString(sum[:])
+	}
+	return keys
+}
+
+// cacheKey is the key of a function's result: everything its analysis depends on, which is
+// its source, its file's package and globals, the declarations of its package it type checks
+// with, the cost models and enabled analysers, the analyser version and the complexity of its
+// callees. A function only needs to be analysed again when one changed
+func cacheKey(units []unit, id int, callees map[string]int, optionsKey string, typesKey string) (string, bool) {
+	decl := units[id].decl
+	fileContext := units[id].fileContext
+	var source strings.Builder
+	if err := format.Node(&source, fileContext.FileSet, decl); err != nil {
+		return "", false
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "version %d\noptions %s\npackage %s\nglobals %q\ntypes %s\n", Version, optionsKey, fileContext.Package, fileContext.Globals, typesKey)
+	if decl.Doc != nil {
+		for _, comment := range decl.Doc.List {
+			fmt.Fprintf(hash, "%s\n", comment.Text)
+		}
+	}
+	fmt.Fprintf(hash, "%s\n", source.String())
+
+	var names []string
+	for _, callee := range units[id].callees {
+		names = append(names, units[callee].decl.Name.Name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if callee, ok := callees[name]; ok {
+			result := units[callee].result
+			fmt.Fprintf(hash, "callee %s %v %v %d\n", name, result.Complexity.Time, result.Complexity.Space, len(result.Unresolved))
+		} else {
+			// called within the same recursive cycle, so the call is cut
+			fmt.Fprintf(hash, "callee %s cut\n", name)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), true
+}
+
+// relocate moves a cached result to where the function is now, it may have moved within
+// its file or to another file since it was cached
+func relocate(cached FunctionInfo, fileContext *FileContext, line int) FunctionInfo {
+	delta := line - cached.Line
+	cached.File = fileContext.FilePath
+	cached.Line += delta
+	cached.EndLine += delta
+	cached.Calls = slices.Clone(cached.Calls)
+	for i := range cached.Calls {
+		cached.Calls[i].Line += delta
+	}
+	cached.Diagnostics = slices.Clone(cached.Diagnostics)
+	for i := range cached.Diagnostics {
+		cached.Diagnostics[i].File = fileContext.FilePath
+		if cached.Diagnostics[i].Line != 0 {
+			cached.Diagnostics[i].Line += delta
+		}
+		cached.Diagnostics[i].Fixes = slices.Clone(cached.Diagnostics[i].Fixes)
+		for j := range cached.Diagnostics[i].Fixes {
+			textEdits := slices.Clone(cached.Diagnostics[i].Fixes[j].TextEdits)
+			for k := range textEdits {
+				textEdits[k].File = fileContext.FilePath
+				textEdits[k].Line += delta
+				textEdits[k].EndLine += delta
+			}
+			cached.Diagnostics[i].Fixes[j].TextEdits = textEdits
+		}
+	}
+	cached.Unresolved = slices.Clone(cached.Unresolved)
+	for i := range cached.Unresolved {
+		cached.Unresolved[i].Line += delta
+	}
+	cached.Evidence = slices.Clone(cached.Evidence)
+	for i := range cached.Evidence {
+		cached.Evidence[i].Line += delta
+	}
+	return cached
+}
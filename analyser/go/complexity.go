@@ -0,0 +1,62 @@
+package analyser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FormatComplexity turns a complexity index into big O notation: 0 is O(1), 0.5 is O(log n),
+// 1 is O(n), 1.5 is O(n*log n), any other whole number k is O(n^k) and k.5 is O(n^k*log n)
+func FormatComplexity(index float32) string {
+	switch index {
+	case 0:
+		return "O(1)"
+	case 0.5:
+		return "O(log n)"
+	case 1:
+		return "O(n)"
+	case 1.5:
+		return "O(n*log n)"
+	}
+	if index != float32(int(index)) {
+		return "O(n^" + strconv.Itoa(int(index)) + "*log n)"
+	}
+	return "O(n^" + strconv.Itoa(int(index)) + ")"
+}
+
+// ParseComplexity is the inverse of FormatComplexity and accepts the usual ways of writing
+// big O notation, like "O(n log n)", "n*logn", "O(n²)" or "O(n^3)"
+func ParseComplexity(complexity string) (float32, error) {
+	normalised := strings.ToLower(strings.Join(strings.Fields(complexity), ""))
+	if strings.HasPrefix(normalised, "o(") && strings.HasSuffix(normalised, ")") {
+		normalised = normalised[2 : len(normalised)-1]
+	}
+	normalised = strings.ReplaceAll(normalised, "*", "")
+	normalised = strings.ReplaceAll(normalised, "·", "")
+
+	switch normalised {
+	case "1":
+		return 0, nil
+	case "logn":
+		return 0.5, nil
+	case "n":
+		return 1, nil
+	case "nlogn":
+		return 1.5, nil
+	case "n²":
+		return 2, nil
+	case "n³":
+		return 3, nil
+	}
+	if power, ok := strings.CutPrefix(normalised, "n^"); ok {
+		power, logarithmic := strings.CutSuffix(power, "logn")
+		if k, err := strconv.Atoi(power); err == nil && k >= 0 {
+			if logarithmic {
+				return float32(k) + 0.5, nil
+			}
+			return float32(k), nil
+		}
+	}
+	return 0, fmt.Errorf("unrecognised complexity %q", complexity)
+}
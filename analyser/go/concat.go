@@ -0,0 +1,65 @@
+package analyser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+)
+
+const RuleStringConcat = "string-concat"
+
+// checkStringConcat finds strings growing by concatenation inside loops bounded by parameters,
+// `s += x` or `s = s + x`. Strings are immutable, so every iteration copies the whole string:
+// the loop costs the square of its iterations in time and in memory allocated
+func (functionContext *FunctionContext) checkStringConcat(stmt *ast.AssignStmt) {
+	if functionContext.paramLoops == 0 || len(stmt.Lhs) != 1 || len(stmt.Rhs) != 1 {
+		return
+	}
+	target := stmt.Lhs[0]
+	switch stmt.Tok {
+	case token.ADD_ASSIGN:
+	case token.ASSIGN:
+		if !concatenates(stmt.Rhs[0], nodeString(target)) {
+			return
+		}
+	default:
+		return
+	}
+	if !functionContext.isString(target) {
+		return
+	}
+
+	functionContext.recordTime(2*functionContext.CurrentDepth, functionContext.Inputs...)
+	total := FormatTerms([]Term{normaliseTerm(append(slices.Clone(functionContext.Inputs), functionContext.Inputs...))})
+	functionContext.Report(stmt.Pos(), RuleStringConcat, fmt.Sprintf("%s is copied on every iteration to grow it, %s in total, build it with a strings.Builder", nodeString(target), total))
+}
+
+// concatenates tells whether an expression is a sum with the target as one of its operands
+func concatenates(expr ast.Expr, target string) bool {
+	binaryExpr, ok := expr.(*ast.BinaryExpr)
+	if !ok || binaryExpr.Op != token.ADD {
+		return false
+	}
+	for _, operand := range []ast.Expr{binaryExpr.X, binaryExpr.Y} {
+		if nodeString(operand) == target || concatenates(operand, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// isString tells whether the type checker found an expression to be a string, expressions of
+// unknown type are not
+func (functionContext *FunctionContext) isString(expr ast.Expr) bool {
+	if functionContext.Types == nil {
+		return false
+	}
+	exprType := functionContext.Types.TypeOf(expr)
+	if exprType == nil {
+		return false
+	}
+	basic, ok := exprType.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsString != 0
+}
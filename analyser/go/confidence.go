@@ -0,0 +1,99 @@
+package analyser
+
+import (
+	"fmt"
+	"go/ast"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Confidence says how much of a function the analyser understood. Whatever it could not
+// classify contributes nothing, so a complexity below high confidence is only a lower bound
+type Confidence string
+
+const (
+	// ConfidenceHigh means every construct of the function was understood
+	ConfidenceHigh Confidence = "high"
+	// ConfidenceMedium means only calls of unknown cost were not understood
+	ConfidenceMedium Confidence = "medium"
+	// ConfidenceLow means a loop, a recursive call or an allocation size was not understood
+	ConfidenceLow Confidence = "low"
+)
+
+const (
+	UnresolvedLoop      = "loop"
+	UnresolvedCall      = "call"
+	UnresolvedRecursion = "recursion"
+	UnresolvedSize      = "size"
+)
+
+// Unresolved is a construct whose contribution to the complexity is unknown, like a loop
+// whose bound is not a parameter or a call through an interface
+type Unresolved struct {
+	Line    int
+	Kind    string
+	Message string
+}
+
+// builtins cost O(1) or are modelled by the visitor itself, conversions to the basic types too
+var builtins = map[string]bool{
+	"append": true, "cap": true, "clear": true, "close": true, "complex": true, "copy": true,
+	"delete": true, "imag": true, "len": true, "make": true, "max": true, "min": true, "new": true,
+	"panic": true, "print": true, "println": true, "real": true, "recover": true,
+	"bool": true, "byte": true, "rune": true, "string": true, "error": true, "any": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"float32": true, "float64": true, "complex64": true, "complex128": true,
+}
+
+// Unresolve records a construct the analyser could not classify
+func (functionContext *FunctionContext) Unresolve(node ast.Node, kind string, format string, args ...any) {
+	unresolved := Unresolved{Kind: kind, Message: fmt.Sprintf(format, args...)}
+	if functionContext.FileSet != nil {
+		unresolved.Line = functionContext.FileSet.Position(node.Pos()).Line
+	}
+	functionContext.Unresolved = append(functionContext.Unresolved, unresolved)
+}
+
+// GetConfidence grades a function by the kinds of constructs that were not understood
+func GetConfidence(unresolved []Unresolved) Confidence {
+	confidence := ConfidenceHigh
+	for _, construct := range unresolved {
+		if construct.Kind != UnresolvedCall {
+			return ConfidenceLow
+		}
+		confidence = ConfidenceMedium
+	}
+	return confidence
+}
+
+// FormatLowerBound formats a complexity index like FormatComplexity, or as a lower bound
+// when some constructs were not understood: "≥ O(n), unknown contributions at lines 12, 15"
+func FormatLowerBound(index float32, unresolved []Unresolved) string {
+	if len(unresolved) == 0 {
+		return FormatComplexity(index)
+	}
+	return fmt.Sprintf("≥ %s, unknown contributions at %s", FormatComplexity(index), unresolvedLines(unresolved))
+}
+
+// unresolvedLines lists the lines of unresolved constructs, like "line 12" or "lines 12, 15"
+func unresolvedLines(unresolved []Unresolved) string {
+	seen := map[int]bool{}
+	var lines []int
+	for _, construct := range unresolved {
+		if !seen[construct.Line] {
+			seen[construct.Line] = true
+			lines = append(lines, construct.Line)
+		}
+	}
+	sort.Ints(lines)
+	var numbers []string
+	for _, line := range lines {
+		numbers = append(numbers, strconv.Itoa(line))
+	}
+	if len(numbers) == 1 {
+		return "line " + numbers[0]
+	}
+	return "lines " + strings.Join(numbers, ", ")
+}
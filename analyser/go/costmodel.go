@@ -0,0 +1,66 @@
+package analyser
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+)
+
+// CostModel gives the complexity of calls the analyser cannot see into, keyed by the
+// callee as written at the call site, e.g. "sort.Slice" or "strings.Repeat"
+type CostModel map[string]Complexity
+
+type costModelEntry struct {
+	Time  string `json:"time" yaml:"time"`
+	Space string `json:"space" yaml:"space"`
+}
+
+// LoadCostModel reads a cost model from a YAML or JSON file:
+//
+//	sort.Slice: {time: O(n log n)}
+//	strings.Repeat: {time: O(n), space: O(n)}
+func LoadCostModel(path string) (CostModel, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	entries := map[string]costModelEntry{}
+	if filepath.Ext(path) == ".json" {
+		err = json.Unmarshal(src, &entries)
+	} else {
+		err = yaml.Unmarshal(src, &entries)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	costModel := CostModel{}
+	for callee, entry := range entries {
+		var complexity Complexity
+		if entry.Time != "" {
+			if complexity.Time, err = ParseComplexity(entry.Time); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", path, callee, err)
+			}
+		}
+		if entry.Space != "" {
+			if complexity.Space, err = ParseComplexity(entry.Space); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", path, callee, err)
+			}
+		}
+		costModel[callee] = complexity
+	}
+	return costModel, nil
+}
+
+// Merge returns a cost model with the entries of all models, later models win
+func (costModel CostModel) Merge(others ...CostModel) CostModel {
+	merged := CostModel{}
+	for _, model := range append([]CostModel{costModel}, others...) {
+		for callee, complexity := range model {
+			merged[callee] = complexity
+		}
+	}
+	return merged
+}
@@ -0,0 +1,180 @@
+package analyser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const directivePrefix = "//funalyser:"
+
+const (
+	RuleDirective = "directive"
+	RuleExpect    = "expect"
+)
+
+// Directives are the `//funalyser:` comments written in a function's doc comment:
+//
+//	//funalyser:expect time=O(n log n) space=O(n)
+//	//funalyser:ignore [rule,...]
+//	//funalyser:assume n<=64
+type Directives struct {
+	ExpectTime  *float32
+	ExpectSpace *float32
+	Ignore      bool
+	IgnoreRules []string
+	Assume      map[string]int
+}
+
+type Diagnostic struct {
+	File     string
+	Line     int
+	Function string
+	Rule     string
+	Message  string
+	// Fixes are edits resolving the diagnostic, applied by funalyser fix
+	Fixes []SuggestedFix
+}
+
+// Advisory tells whether the diagnostic only suggests an improvement, like prealloc or
+// linear-search. funalyser check fails on the others: threshold breaches, expect mismatches,
+// malformed directives and what could not be analysed
+func (d Diagnostic) Advisory() bool {
+	switch d.Rule {
+	case RuleThreshold, RuleExpect, RuleDirective, RuleSyntax, RuleLimit:
+		return false
+	}
+	return true
+}
+
+var (
+	expectationPattern = regexp.MustCompile(`(\w+)=`)
+	assumptionPattern  = regexp.MustCompile(`^(?:len\((\w+)\)|(\w+))(<=|<)(\d+)$`)
+)
+
+// ParseDirectives reads the directives of a function's doc comment, malformed ones are
+// reported as diagnostics instead
+func ParseDirectives(decl *ast.FuncDecl, fileContext *FileContext) (Directives, []Diagnostic) {
+	var directives Directives
+	var diagnostics []Diagnostic
+	if decl.Doc == nil {
+		return directives, diagnostics
+	}
+
+	for _, comment := range decl.Doc.List {
+		text, ok := strings.CutPrefix(comment.Text, directivePrefix)
+		if !ok {
+			continue
+		}
+		report := func(format string, args ...any) {
+			diagnostics = append(diagnostics, newDiagnostic(fileContext, comment.Pos(), decl.Name.Name, RuleDirective, fmt.Sprintf(format, args...)))
+		}
+		name, arguments, _ := strings.Cut(text, " ")
+		arguments = strings.TrimSpace(arguments)
+
+		switch name {
+		case "expect":
+			// values may contain spaces, as in "time=O(n log n)", so each runs up to the next key
+			keys := expectationPattern.FindAllStringSubmatchIndex(arguments, -1)
+			if len(keys) == 0 {
+				report("expect needs time= or space=, got %q", arguments)
+			}
+			for i, key := range keys {
+				end := len(arguments)
+				if i+1 < len(keys) {
+					end = keys[i+1][0]
+				}
+				complexity, err := ParseComplexity(arguments[key[1]:end])
+				if err != nil {
+					report("%s", err)
+					continue
+				}
+				switch arguments[key[2]:key[3]] {
+				case "time":
+					directives.ExpectTime = &complexity
+				case "space":
+					directives.ExpectSpace = &complexity
+				default:
+					report("unknown expectation %q, use time= or space=", arguments[key[2]:key[3]])
+				}
+			}
+
+		case "ignore":
+			directives.Ignore = true
+			for _, rule := range strings.Split(arguments, ",") {
+				if rule = strings.TrimSpace(rule); rule != "" {
+					directives.IgnoreRules = append(directives.IgnoreRules, rule)
+				}
+			}
+
+		case "assume":
+			for _, assumption := range strings.Split(arguments, ",") {
+				match := assumptionPattern.FindStringSubmatch(strings.ReplaceAll(assumption, " ", ""))
+				if match == nil {
+					report("cannot read assumption %q, use n<=64 or len(items)<=64", assumption)
+					continue
+				}
+				variable := match[1] + match[2]
+				bound, _ := strconv.Atoi(match[4])
+				if match[3] == "<" {
+					bound--
+				}
+				if directives.Assume == nil {
+					directives.Assume = map[string]int{}
+				}
+				directives.Assume[variable] = bound
+			}
+
+		default:
+			report("unknown directive %q", name)
+		}
+	}
+	return directives, diagnostics
+}
+
+// Ignores tells whether findings of a rule are suppressed by an ignore directive
+func (directives Directives) Ignores(rule string) bool {
+	if !directives.Ignore {
+		return false
+	}
+	if len(directives.IgnoreRules) == 0 {
+		return true
+	}
+	for _, ignored := range directives.IgnoreRules {
+		if ignored == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckExpectations reports the expect directives that contradict the inferred complexity
+func CheckExpectations(functionContext *FunctionContext) []Diagnostic {
+	var diagnostics []Diagnostic
+	directives := functionContext.Directives
+	check := func(kind string, expected *float32, got float32) {
+		if expected != nil && *expected != got {
+			diagnostics = append(diagnostics, Diagnostic{
+				File:     functionContext.File,
+				Line:     functionContext.Line,
+				Function: functionContext.Name,
+				Rule:     RuleExpect,
+				Message:  fmt.Sprintf("expected %s complexity %s, inferred %s", kind, FormatComplexity(*expected), FormatComplexity(got)),
+			})
+		}
+	}
+	check("time", directives.ExpectTime, functionContext.MaxDepth)
+	check("space", directives.ExpectSpace, functionContext.MaxMalloc)
+	return diagnostics
+}
+
+func newDiagnostic(fileContext *FileContext, pos token.Pos, function string, rule string, message string) Diagnostic {
+	diagnostic := Diagnostic{File: fileContext.FilePath, Function: function, Rule: rule, Message: message}
+	if fileContext.FileSet != nil {
+		diagnostic.Line = fileContext.FileSet.Position(pos).Line
+	}
+	return diagnostic
+}
@@ -0,0 +1,36 @@
+package analyser
+
+import (
+	"fmt"
+	"go/ast"
+)
+
+const (
+	EvidenceLoop       = "loop"
+	EvidenceAllocation = "allocation"
+	EvidenceRecursion  = "recursion"
+	EvidenceCall       = "call"
+)
+
+// Evidence is a construct the visitor counted towards the complexity of a function, like a
+// loop over an input or an allocation growing with one. Time and Space are the complexity
+// indexes the function reaches with it, so the nested loop of O(n^2) has Time 2
+type Evidence struct {
+	Line    int
+	Kind    string
+	Message string
+	Time    float32
+	Space   float32
+}
+
+// addEvidence records a construct that contributes to the complexity, constant ones do not
+func (functionContext *FunctionContext) addEvidence(node ast.Node, kind string, time float32, space float32, format string, args ...any) {
+	if time == 0 && space == 0 {
+		return
+	}
+	evidence := Evidence{Kind: kind, Message: fmt.Sprintf(format, args...), Time: time, Space: space}
+	if functionContext.FileSet != nil {
+		evidence.Line = functionContext.FileSet.Position(node.Pos()).Line
+	}
+	functionContext.Evidence = append(functionContext.Evidence, evidence)
+}
@@ -0,0 +1,359 @@
+package analyser
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"golang.org/x/tools/go/ast/astutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SuggestedFix is a change that resolves a diagnostic, like go/analysis' SuggestedFix
+type SuggestedFix struct {
+	Message   string
+	TextEdits []TextEdit
+}
+
+// TextEdit replaces the text between two positions of a file, inserts when they are equal.
+// Lines and columns count from 1 and columns in bytes, like token.Position
+type TextEdit struct {
+	File      string
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
+	NewText   string
+}
+
+// replace is an edit replacing the source of a node
+func (functionContext *FunctionContext) replace(node ast.Node, newText string) TextEdit {
+	return functionContext.edit(node.Pos(), node.End(), newText)
+}
+
+// insertBefore is an edit inserting a statement before another, on its own line. Labeled
+// statements get it before their label
+func (functionContext *FunctionContext) insertBefore(stmt ast.Stmt, newText string) TextEdit {
+	if label, ok := functionContext.labels[stmt]; ok {
+		stmt = label
+	}
+	return functionContext.edit(stmt.Pos(), stmt.Pos(), newText+"\n")
+}
+
+// deleteLines is an edit removing the lines of a statement
+func (functionContext *FunctionContext) deleteLines(stmt ast.Node) TextEdit {
+	start := functionContext.FileSet.Position(stmt.Pos())
+	end := functionContext.FileSet.Position(stmt.End())
+	return TextEdit{File: start.Filename, Line: start.Line, Column: 1, EndLine: end.Line + 1, EndColumn: 1}
+}
+
+func (functionContext *FunctionContext) edit(from token.Pos, to token.Pos, newText string) TextEdit {
+	start := functionContext.FileSet.Position(from)
+	end := functionContext.FileSet.Position(to)
+	return TextEdit{File: start.Filename, Line: start.Line, Column: start.Column, EndLine: end.Line, EndColumn: end.Column, NewText: newText}
+}
+
+// removeImport is the edit removing the import of the package a selector like slices.Contains
+// reads, when the selector is its only use in the file
+func (functionContext *FunctionContext) removeImport(expr ast.Expr) (TextEdit, bool) {
+	selector, ok := expr.(*ast.SelectorExpr)
+	if !ok || functionContext.FileSet == nil {
+		return TextEdit{}, false
+	}
+	ident, ok := selector.X.(*ast.Ident)
+	if !ok {
+		return TextEdit{}, false
+	}
+	pkgName, ok := functionContext.Types.Uses[ident].(*types.PkgName)
+	if !ok {
+		return TextEdit{}, false
+	}
+	for use, object := range functionContext.Types.Uses {
+		if object == pkgName && use != ident {
+			return TextEdit{}, false
+		}
+	}
+	fset := functionContext.FileSet
+	for _, decl := range functionContext.imports {
+		for i, spec := range decl.Specs {
+			if spec.Pos() != pkgName.Pos() {
+				continue
+			}
+			if len(decl.Specs) == 1 {
+				return functionContext.deleteLines(decl), true
+			}
+			// other imports on its lines would go with it
+			if i > 0 && fset.Position(decl.Specs[i-1].End()).Line >= fset.Position(spec.Pos()).Line ||
+				i < len(decl.Specs)-1 && fset.Position(decl.Specs[i+1].Pos()).Line <= fset.Position(spec.End()).Line {
+				return TextEdit{}, false
+			}
+			return functionContext.deleteLines(spec), true
+		}
+	}
+	return TextEdit{}, false
+}
+
+// visibleAt tells whether the identifiers of an expression name the same objects at pos as
+// where the expression is, so that it can be moved there. Fields are looked up on what they
+// select from
+func (functionContext *FunctionContext) visibleAt(expr ast.Expr, pos token.Pos) bool {
+	if functionContext.scope == nil {
+		return false
+	}
+	scope := functionContext.scope.Innermost(pos)
+	visible := scope != nil
+	ast.Inspect(expr, func(node ast.Node) bool {
+		switch exp := node.(type) {
+		case *ast.SelectorExpr:
+			visible = visible && functionContext.visibleAt(exp.X, pos)
+			return false
+		case *ast.Ident:
+			object := functionContext.Types.Uses[exp]
+			if _, found := scope.LookupParent(exp.Name, pos); object == nil || found != object {
+				visible = false
+			}
+		}
+		return visible
+	})
+	return visible
+}
+
+// freeName is name, or name2, name3 and so on, whichever nothing is called at pos nor in the
+// function, so that declaring it at pos neither clashes with nor shadows another identifier
+func (functionContext *FunctionContext) freeName(name string, pos token.Pos) string {
+	used := map[string]bool{}
+	ast.Inspect(functionContext.body, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok {
+			used[ident.Name] = true
+		}
+		return true
+	})
+	var scope *types.Scope
+	if functionContext.scope != nil {
+		scope = functionContext.scope.Innermost(pos)
+	}
+	taken := func(name string) bool {
+		if used[name] || scope == nil {
+			return used[name]
+		}
+		_, found := scope.LookupParent(name, pos)
+		return found != nil
+	}
+	free := name
+	for i := 2; taken(free); i++ {
+		free = fmt.Sprintf("%s%d", name, i)
+	}
+	return free
+}
+
+// ownLine tells whether a statement is the only one on its lines, so that moving its lines
+// does not take other statements along
+func ownLine(fset *token.FileSet, stmts []ast.Stmt, i int) bool {
+	if i > 0 && fset.Position(stmts[i-1].End()).Line >= fset.Position(stmts[i].Pos()).Line {
+		return false
+	}
+	if i < len(stmts)-1 && fset.Position(stmts[i+1].Pos()).Line <= fset.Position(stmts[i].End()).Line {
+		return false
+	}
+	return true
+}
+
+// ApplyFixes applies the edits of fixes to the source of a file and formats the result with
+// go/format. Identical edits, made by several fixes, are applied once, and edits overlapping
+// an earlier one are skipped. Imports the edits left unused, like slices once every
+// slices.Contains was replaced, are removed. It returns how many fixes were applied in full
+func ApplyFixes(src []byte, fixes []SuggestedFix) ([]byte, int, error) {
+	lineStarts := []int{0}
+	for offset, char := range src {
+		if char == '\n' {
+			lineStarts = append(lineStarts, offset+1)
+		}
+	}
+	offset := func(line int, column int) (int, error) {
+		if line < 1 || line > len(lineStarts) {
+			// the line after the last one ends the file
+			if line == len(lineStarts)+1 && column == 1 {
+				return len(src), nil
+			}
+			return 0, fmt.Errorf("line %d is out of range", line)
+		}
+		position := lineStarts[line-1] + column - 1
+		if position > len(src) {
+			return 0, fmt.Errorf("column %d of line %d is out of range", column, line)
+		}
+		return position, nil
+	}
+
+	type change struct {
+		start, end int
+		newText    string
+	}
+	var changes []change
+	applied := 0
+	for _, fix := range fixes {
+		var fixChanges []change
+		conflicts := false
+		for _, textEdit := range fix.TextEdits {
+			start, err := offset(textEdit.Line, textEdit.Column)
+			if err != nil {
+				return nil, 0, err
+			}
+			end, err := offset(textEdit.EndLine, textEdit.EndColumn)
+			if err != nil {
+				return nil, 0, err
+			}
+			candidate := change{start, end, textEdit.NewText}
+			for _, existing := range changes {
+				if existing == candidate {
+					candidate.start = -1
+				} else if start < existing.end && existing.start < end {
+					conflicts = true
+				}
+			}
+			if candidate.start >= 0 {
+				fixChanges = append(fixChanges, candidate)
+			}
+		}
+		if conflicts {
+			continue
+		}
+		changes = append(changes, fixChanges...)
+		applied++
+	}
+
+	// from the end of the file, so that offsets stay valid, and replacements before insertions
+	// at the same offset
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].start != changes[j].start {
+			return changes[i].start > changes[j].start
+		}
+		return changes[i].end > changes[j].end
+	})
+	fixed := bytes.Clone(src)
+	for _, change := range changes {
+		fixed = append(fixed[:change.start], append([]byte(change.newText), fixed[change.end:]...)...)
+	}
+	fixed, err := removeUnusedImports(src, fixed)
+	if err != nil {
+		return nil, 0, fmt.Errorf("the fixed source does not parse: %w", err)
+	}
+	formatted, err := format.Source(fixed)
+	if err != nil {
+		return nil, 0, fmt.Errorf("the fixed source does not parse: %w", err)
+	}
+	return formatted, applied, nil
+}
+
+// removeUnusedImports removes the imports of the fixed source that the original one used and
+// it no longer does. Imports that were already unused are left to the compiler to report
+func removeUnusedImports(src []byte, fixed []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	original, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		return fixed, nil
+	}
+	file, err := parser.ParseFile(fset, "", fixed, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	removed := false
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || spec.Name != nil && (spec.Name.Name == "_" || spec.Name.Name == ".") {
+			continue
+		}
+		if !astutil.UsesImport(file, path) && astutil.UsesImport(original, path) {
+			name := ""
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			removed = astutil.DeleteNamedImport(fset, file, name, path) || removed
+		}
+	}
+	if !removed {
+		return fixed, nil
+	}
+	var out bytes.Buffer
+	if err := format.Node(&out, fset, file); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// CheckFixes type checks the package of a file with the file replaced by its fixed source.
+// It fails with the errors the fixes introduced, those that type checking the original
+// source does not raise too, so that fixes never break a build
+func CheckFixes(filePath string, src []byte, fixed []byte) error {
+	before, err := typeErrors(filePath, src)
+	if err != nil {
+		return err
+	}
+	after, err := typeErrors(filePath, fixed)
+	if err != nil {
+		return err
+	}
+	var introduced []string
+	for message, count := range after {
+		if count > before[message] {
+			introduced = append(introduced, message)
+		}
+	}
+	if len(introduced) > 0 {
+		sort.Strings(introduced)
+		return fmt.Errorf("the fixed source does not type check: %s", strings.Join(introduced, "; "))
+	}
+	return nil
+}
+
+// typeErrors type checks the package of a file, with src as the source of the file, and
+// counts its errors by message. The other files of the package are read from its directory
+func typeErrors(filePath string, src []byte) (map[string]int, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filePath, src, 0)
+	if err != nil {
+		return nil, err
+	}
+	files := []*ast.File{file}
+	siblings, err := filepath.Glob(filepath.Join(filepath.Dir(filePath), "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	for _, sibling := range siblings {
+		if sameFile(sibling, filePath) || strings.HasSuffix(sibling, "_test.go") != strings.HasSuffix(filePath, "_test.go") {
+			continue
+		}
+		siblingFile, err := parser.ParseFile(fset, sibling, nil, 0)
+		if err != nil || siblingFile.Name.Name != file.Name.Name {
+			continue
+		}
+		files = append(files, siblingFile)
+	}
+	messages := map[string]int{}
+	config := types.Config{
+		Importer: importer.Default(),
+		Error: func(err error) {
+			if typeError, ok := err.(types.Error); ok {
+				messages[typeError.Msg]++
+			}
+		},
+	}
+	config.Check(file.Name.Name, fset, files, nil)
+	return messages, nil
+}
+
+func sameFile(path string, other string) bool {
+	pathInfo, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	otherInfo, err := os.Stat(other)
+	return err == nil && os.SameFile(pathInfo, otherInfo)
+}
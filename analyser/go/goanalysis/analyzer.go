@@ -0,0 +1,208 @@
+// Package goanalysis packages the complexity analyser as a golang.org/x/tools/go/analysis
+// Analyzer, so it runs in go vet style multicheckers and in gopls
+package goanalysis
+
+import (
+	"fmt"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"golang.org/x/tools/go/analysis"
+)
+
+var Analyzer = &analysis.Analyzer{
+	Name:      "complexity",
+	Doc:       "report functions whose time or space complexity breaks a threshold or contradicts a //funalyser:expect directive",
+	URL:       "https://github.com/DanyloPiatyhorets/funalyser",
+	Run:       run,
+	FactTypes: []analysis.Fact{new(ComplexityFact)},
+}
+
+var maxTime, maxSpace string
+
+func init() {
+	Analyzer.Flags.StringVar(&maxTime, "max-time", "", "highest time complexity allowed, e.g. O(n^2)")
+	Analyzer.Flags.StringVar(&maxSpace, "max-space", "", "highest space complexity allowed, e.g. O(n)")
+}
+
+// ComplexityFact is the complexity of a function, exported so that callers in other
+// packages account for the cost of calling it
+type ComplexityFact struct {
+	Time  float32
+	Space float32
+}
+
+func (*ComplexityFact) AFact() {}
+
+func (fact *ComplexityFact) String() string {
+	return fmt.Sprintf("time=%s space=%s", analyser.FormatComplexity(fact.Time), analyser.FormatComplexity(fact.Space))
+}
+
+type packageAnalysis struct {
+	pass       *analysis.Pass
+	thresholds analyser.Thresholds
+	decls      map[*types.Func]*ast.FuncDecl
+	files      map[*ast.FuncDecl]*analyser.FileContext
+	results    map[*types.Func]*analyser.FunctionInfo
+	inProgress map[*types.Func]bool
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	thresholds, err := parseThresholds()
+	if err != nil {
+		return nil, err
+	}
+	packageAnalysis := &packageAnalysis{
+		pass:       pass,
+		thresholds: thresholds,
+		decls:      map[*types.Func]*ast.FuncDecl{},
+		files:      map[*ast.FuncDecl]*analyser.FileContext{},
+		results:    map[*types.Func]*analyser.FunctionInfo{},
+		inProgress: map[*types.Func]bool{},
+	}
+
+	var funcs []*types.Func
+	for _, file := range pass.Files {
+		fileContext := analyser.GetFileContext(file)
+		fileContext.FileSet = pass.Fset
+		fileContext.FilePath = pass.Fset.Position(file.Pos()).Filename
+		fileContext.Types = pass.TypesInfo
+		for _, declaration := range file.Decls {
+			decl, ok := declaration.(*ast.FuncDecl)
+			if !ok || decl.Body == nil {
+				continue
+			}
+			fn, ok := pass.TypesInfo.Defs[decl.Name].(*types.Func)
+			if !ok {
+				continue
+			}
+			packageAnalysis.decls[fn] = decl
+			packageAnalysis.files[decl] = &fileContext
+			funcs = append(funcs, fn)
+		}
+	}
+
+	for _, fn := range funcs {
+		functionInfo := packageAnalysis.analyse(fn)
+		pass.ExportObjectFact(fn, &ComplexityFact{Time: functionInfo.Complexity.Time, Space: functionInfo.Complexity.Space})
+		packageAnalysis.report(packageAnalysis.decls[fn], functionInfo)
+	}
+	return nil, nil
+}
+
+// analyse computes the complexity of a function of the package, analysing the functions
+// it calls first so that their cost is known. Recursive cycles are cut where they close
+func (packageAnalysis *packageAnalysis) analyse(fn *types.Func) *analyser.FunctionInfo {
+	if functionInfo, ok := packageAnalysis.results[fn]; ok {
+		return functionInfo
+	}
+	packageAnalysis.inProgress[fn] = true
+	defer delete(packageAnalysis.inProgress, fn)
+
+	decl := packageAnalysis.decls[fn]
+	functionContext := analyser.GetFunctionContext(decl, packageAnalysis.files[decl])
+	analyser.Walk(decl.Body, functionContext, &analyser.TimeAndSpaceComplexityAnalyser{Callee: packageAnalysis.callee})
+	functionInfo := analyser.ParseContextToInfo(functionContext)
+	packageAnalysis.results[fn] = &functionInfo
+	return &functionInfo
+}
+
+func (packageAnalysis *packageAnalysis) callee(call *ast.CallExpr) (analyser.Complexity, bool) {
+	var ident *ast.Ident
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	default:
+		return analyser.Complexity{}, false
+	}
+	fn, ok := packageAnalysis.pass.TypesInfo.Uses[ident].(*types.Func)
+	if !ok {
+		return analyser.Complexity{}, false
+	}
+	fn = fn.Origin()
+
+	if fn.Pkg() == packageAnalysis.pass.Pkg {
+		if _, ok := packageAnalysis.decls[fn]; !ok || packageAnalysis.inProgress[fn] {
+			return analyser.Complexity{}, false
+		}
+		return packageAnalysis.analyse(fn).Complexity, true
+	}
+	var fact ComplexityFact
+	if !packageAnalysis.pass.ImportObjectFact(fn, &fact) {
+		return analyser.Complexity{}, false
+	}
+	return analyser.Complexity{Time: fact.Time, Space: fact.Space}, true
+}
+
+func (packageAnalysis *packageAnalysis) report(decl *ast.FuncDecl, functionInfo *analyser.FunctionInfo) {
+	diagnostics := append(functionInfo.Diagnostics, packageAnalysis.thresholds.Check(*functionInfo)...)
+	for _, diagnostic := range diagnostics {
+		packageAnalysis.pass.Report(analysis.Diagnostic{
+			Pos:            packageAnalysis.position(decl, diagnostic),
+			Category:       diagnostic.Rule,
+			Message:        diagnostic.Message,
+			SuggestedFixes: packageAnalysis.suggestedFixes(decl, diagnostic.Fixes),
+		})
+	}
+}
+
+// suggestedFixes maps the line and column edits of fixes back to token.Pos
+func (packageAnalysis *packageAnalysis) suggestedFixes(decl *ast.FuncDecl, fixes []analyser.SuggestedFix) []analysis.SuggestedFix {
+	file := packageAnalysis.pass.Fset.File(decl.Pos())
+	if file == nil {
+		return nil
+	}
+	pos := func(line int, column int) token.Pos {
+		if line > file.LineCount() {
+			return token.Pos(file.Base() + file.Size())
+		}
+		return file.LineStart(line) + token.Pos(column-1)
+	}
+	var suggestedFixes []analysis.SuggestedFix
+	for _, fix := range fixes {
+		suggestedFix := analysis.SuggestedFix{Message: fix.Message}
+		for _, textEdit := range fix.TextEdits {
+			suggestedFix.TextEdits = append(suggestedFix.TextEdits, analysis.TextEdit{
+				Pos:     pos(textEdit.Line, textEdit.Column),
+				End:     pos(textEdit.EndLine, textEdit.EndColumn),
+				NewText: []byte(textEdit.NewText),
+			})
+		}
+		suggestedFixes = append(suggestedFixes, suggestedFix)
+	}
+	return suggestedFixes
+}
+
+// position maps a diagnostic back to a token.Pos, function level findings point at the name
+func (packageAnalysis *packageAnalysis) position(decl *ast.FuncDecl, diagnostic analyser.Diagnostic) token.Pos {
+	file := packageAnalysis.pass.Fset.File(decl.Pos())
+	if diagnostic.Line == 0 || file == nil || diagnostic.Line == file.Line(decl.Pos()) {
+		return decl.Name.Pos()
+	}
+	if diagnostic.Line > file.LineCount() {
+		return decl.Name.Pos()
+	}
+	return file.LineStart(diagnostic.Line)
+}
+
+func parseThresholds() (analyser.Thresholds, error) {
+	var thresholds analyser.Thresholds
+	if maxTime != "" {
+		time, err := analyser.ParseComplexity(maxTime)
+		if err != nil {
+			return thresholds, fmt.Errorf("-max-time: %w", err)
+		}
+		thresholds.Time = &time
+	}
+	if maxSpace != "" {
+		space, err := analyser.ParseComplexity(maxSpace)
+		if err != nil {
+			return thresholds, fmt.Errorf("-max-space: %w", err)
+		}
+		thresholds.Space = &space
+	}
+	return thresholds, nil
+}
@@ -0,0 +1,275 @@
+package analyser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"math"
+)
+
+const (
+	RuleLoopInvariant = "loop-invariant"
+	RuleDeferInLoop   = "defer-in-loop"
+)
+
+// expensiveCalls do costly setup whose result only depends on the arguments, like compiling a
+// regular expression or loading a time zone
+var expensiveCalls = map[string]string{
+	"regexp.Compile":          "compiles the same regular expression",
+	"regexp.MustCompile":      "compiles the same regular expression",
+	"regexp.CompilePOSIX":     "compiles the same regular expression",
+	"regexp.MustCompilePOSIX": "compiles the same regular expression",
+	"template.New":            "builds the same template",
+	"template.ParseFiles":     "parses the same template files",
+	"template.ParseGlob":      "parses the same template files",
+	"json.Marshal":            "encodes the same value",
+	"json.MarshalIndent":      "encodes the same value",
+	"time.LoadLocation":       "loads the same time zone",
+}
+
+// checkLoopSetup reports what a loop body repeats for nothing: expensive calls whose arguments
+// cannot change from one iteration to the next, and defer statements, which only run when the
+// function returns so that the deferred calls pile up. Nested loops are checked when visited
+func (functionContext *FunctionContext) checkLoopSetup(loop ast.Stmt, body *ast.BlockStmt) {
+	variants := assignedIn(loop)
+	changed := functionContext.changedIn(loop)
+	line := 0
+	if functionContext.FileSet != nil {
+		line = functionContext.FileSet.Position(loop.Pos()).Line
+	}
+	times := ""
+	if len(functionContext.Inputs) > 0 {
+		times = ", " + FormatTerms([]Term{normaliseTerm(functionContext.Inputs)}) + " times"
+	}
+	hoists := functionContext.hoists(loop, body, variants, changed)
+
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch stmt := node.(type) {
+		case *ast.ForStmt, *ast.RangeStmt, *ast.FuncLit:
+			return false
+		case *ast.DeferStmt:
+			functionContext.MaxMalloc = float32(math.Max(float64(functionContext.CurrentDepth), float64(functionContext.MaxMalloc)))
+			functionContext.own.Space = max(functionContext.own.Space, functionContext.CurrentDepth)
+			growth := ""
+			if len(functionContext.Inputs) > 0 {
+				growth = ", " + FormatTerms([]Term{normaliseTerm(functionContext.Inputs)}) + " memory"
+			}
+			functionContext.Report(stmt.Pos(), RuleDeferInLoop, fmt.Sprintf("deferred calls pile up on every iteration of the loop at line %d until the function returns%s, move the loop body into a function", line, growth))
+		case *ast.CallExpr:
+			waste, ok := expensiveCalls[CalleeName(stmt)]
+			if !ok || !functionContext.invariantArguments(stmt, variants, changed) {
+				return true
+			}
+			var fixes []SuggestedFix
+			if hoist, ok := hoists[stmt]; ok {
+				fixes = append(fixes, hoist)
+			}
+			functionContext.Report(stmt.Pos(), RuleLoopInvariant, fmt.Sprintf("%s %s on every iteration of the loop at line %d%s, call it once before the loop", CalleeName(stmt), waste, line, times), fixes...)
+		}
+		return true
+	})
+}
+
+// hoists are the fixes moving `x := expensive(...)` statements of a loop body before the loop,
+// by the call they make. Only calls with invariant arguments and variables declared once in the
+// loop are moved, so that the program does the same
+func (functionContext *FunctionContext) hoists(loop ast.Stmt, body *ast.BlockStmt, variants []string, changed map[types.Object]bool) map[*ast.CallExpr]SuggestedFix {
+	hoists := map[*ast.CallExpr]SuggestedFix{}
+	if functionContext.FileSet == nil {
+		return hoists
+	}
+	for i, stmt := range body.List {
+		assignStmt, ok := stmt.(*ast.AssignStmt)
+		if !ok || assignStmt.Tok != token.DEFINE || len(assignStmt.Rhs) != 1 || !ownLine(functionContext.FileSet, body.List, i) {
+			continue
+		}
+		call, ok := assignStmt.Rhs[0].(*ast.CallExpr)
+		if !ok || !functionContext.invariantArguments(call, variants, changed) {
+			continue
+		}
+		declaredOnce := true
+		for _, lhs := range assignStmt.Lhs {
+			if ident, ok := lhs.(*ast.Ident); !ok || count(variants, ident.Name) != 1 {
+				declaredOnce = false
+			}
+		}
+		if !declaredOnce {
+			continue
+		}
+		hoists[call] = SuggestedFix{
+			Message: fmt.Sprintf("move %s before the loop", nodeString(assignStmt)),
+			TextEdits: []TextEdit{
+				functionContext.insertBefore(loop, nodeString(assignStmt)),
+				functionContext.deleteLines(assignStmt),
+			},
+		}
+	}
+	return hoists
+}
+
+// invariantArguments tells whether every argument of a call is the same on every iteration of
+// a loop: constants, and local variables the loop neither assigns nor changes otherwise. Calls
+// and package variables can give something else on every iteration
+func (functionContext *FunctionContext) invariantArguments(call *ast.CallExpr, variants []string, changed map[types.Object]bool) bool {
+	for _, arg := range call.Args {
+		if literal, ok := arg.(*ast.BasicLit); ok && literal.Kind == token.STRING {
+			continue
+		}
+		if functionContext.Types == nil {
+			return false
+		}
+		if functionContext.Types.Types[arg].Value != nil {
+			continue
+		}
+		invariant := true
+		ast.Inspect(arg, func(node ast.Node) bool {
+			switch exp := node.(type) {
+			case *ast.CallExpr:
+				// conversions like []byte(name) are fine
+				if !functionContext.Types.Types[exp.Fun].IsType() {
+					invariant = false
+				}
+			case *ast.FuncLit:
+				invariant = false
+			case *ast.Ident:
+				variable, ok := functionContext.Types.Uses[exp].(*types.Var)
+				if !ok || variable.IsField() {
+					return true
+				}
+				packageLevel := variable.Pkg() != nil && variable.Parent() == variable.Pkg().Scope()
+				if packageLevel || count(variants, exp.Name) > 0 || changed[variable] {
+					invariant = false
+				}
+			}
+			return invariant
+		})
+		if !invariant {
+			return false
+		}
+	}
+	return true
+}
+
+// changedIn lists the variables a loop can change without assigning them: those it calls a
+// method on, takes the address of, or passes to another call when they refer to shared memory,
+// like a pointer, a slice or a map
+func (functionContext *FunctionContext) changedIn(loop ast.Stmt) map[types.Object]bool {
+	changed := map[types.Object]bool{}
+	if functionContext.Types == nil {
+		return changed
+	}
+	change := func(expr ast.Expr) {
+		if root := rootIdent(expr); root != nil {
+			if variable, ok := functionContext.Types.Uses[root].(*types.Var); ok {
+				changed[variable] = true
+			}
+		}
+	}
+	ast.Inspect(loop, func(node ast.Node) bool {
+		switch exp := node.(type) {
+		case *ast.UnaryExpr:
+			if exp.Op == token.AND {
+				change(exp.X)
+			}
+		case *ast.CallExpr:
+			// a call through a variable is a method or a function field, either may change it
+			if selector, ok := exp.Fun.(*ast.SelectorExpr); ok {
+				change(selector.X)
+			}
+			if _, ok := expensiveCalls[CalleeName(exp)]; ok {
+				return true
+			}
+			for _, arg := range exp.Args {
+				if !valueType(functionContext.Types.TypeOf(arg)) {
+					change(arg)
+				}
+			}
+		}
+		return true
+	})
+	return changed
+}
+
+// valueType tells whether values of a type are copied whole when passed, so that the callee
+// cannot change the caller's. Types that could not be resolved are not
+func valueType(typ types.Type) bool {
+	if typ == nil {
+		return false
+	}
+	switch underlying := typ.Underlying().(type) {
+	case *types.Basic:
+		return underlying.Kind() != types.Invalid && underlying.Kind() != types.UnsafePointer
+	case *types.Struct:
+		for field := range underlying.Fields() {
+			if !valueType(field.Type()) {
+				return false
+			}
+		}
+		return true
+	case *types.Array:
+		return valueType(underlying.Elem())
+	}
+	return false
+}
+
+func count(names []string, name string) int {
+	n := 0
+	for _, other := range names {
+		if other == name {
+			n++
+		}
+	}
+	return n
+}
+
+// assignedIn lists the variables that change from one iteration of a loop to the next: the
+// loop variables and whatever the body assigns or increments
+func assignedIn(loop ast.Stmt) []string {
+	variables := loopVariables(loop)
+	if forStmt, ok := loop.(*ast.ForStmt); ok {
+		variables = append(variables, loopVariables(forStmt.Init)...)
+	}
+	ast.Inspect(loop, func(node ast.Node) bool {
+		var targets []ast.Expr
+		switch stmt := node.(type) {
+		case *ast.AssignStmt:
+			targets = stmt.Lhs
+		case *ast.IncDecStmt:
+			targets = []ast.Expr{stmt.X}
+		case *ast.RangeStmt:
+			targets = []ast.Expr{stmt.Key, stmt.Value}
+		case *ast.ValueSpec:
+			for _, name := range stmt.Names {
+				targets = append(targets, name)
+			}
+		}
+		for _, target := range targets {
+			if root := rootIdent(target); root != nil {
+				variables = append(variables, root.Name)
+			}
+		}
+		return true
+	})
+	return variables
+}
+
+// rootIdent is the variable an assignment target like items[i].name belongs to
+func rootIdent(expr ast.Expr) *ast.Ident {
+	for {
+		switch exp := expr.(type) {
+		case *ast.Ident:
+			return exp
+		case *ast.SelectorExpr:
+			expr = exp.X
+		case *ast.IndexExpr:
+			expr = exp.X
+		case *ast.StarExpr:
+			expr = exp.X
+		case *ast.ParenExpr:
+			expr = exp.X
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package analyser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+)
+
+const RuleLinearSearch = "linear-search"
+
+// linearSearches are the calls that scan their first argument for a match
+var linearSearches = map[string]bool{
+	"slices.Contains":     true,
+	"slices.ContainsFunc": true,
+	"slices.Index":        true,
+	"slices.IndexFunc":    true,
+}
+
+// linearSearch returns the collection a call like slices.Contains(bs, a) searches
+func linearSearch(call *ast.CallExpr) (ast.Expr, bool) {
+	if !linearSearches[CalleeName(call)] || len(call.Args) == 0 {
+		return nil, false
+	}
+	return call.Args[0], true
+}
+
+// checkSearchCall reports a call searching a collection linearly inside a loop
+func (functionContext *FunctionContext) checkSearchCall(call *ast.CallExpr, collection ast.Expr) {
+	if len(functionContext.loops) == 0 {
+		return
+	}
+	outer := functionContext.loops[len(functionContext.loops)-1]
+	factors := append(slices.Clone(functionContext.Inputs), functionContext.rangeFactor(collection))
+	var fixes []SuggestedFix
+	if fix, ok := functionContext.setLookup(call, outer, collection); ok {
+		fixes = append(fixes, fix)
+	}
+	functionContext.reportSearch(call, outer, collection, factors, fixes...)
+}
+
+// setLookup is the fix turning slices.Contains(bs, a) inside a loop into a lookup in a set
+// built before the loop, when bs does not change in the loop and its elements are comparable
+func (functionContext *FunctionContext) setLookup(call *ast.CallExpr, outer ast.Stmt, collection ast.Expr) (SuggestedFix, bool) {
+	if CalleeName(call) != "slices.Contains" || len(call.Args) != 2 || functionContext.Types == nil || functionContext.FileSet == nil {
+		return SuggestedFix{}, false
+	}
+	root := rootIdent(collection)
+	if !stable(collection) || root == nil || slices.Contains(assignedIn(outer), root.Name) || !functionContext.visibleAt(collection, outer.Pos()) {
+		return SuggestedFix{}, false
+	}
+	collectionType := functionContext.Types.TypeOf(collection)
+	if collectionType == nil {
+		return SuggestedFix{}, false
+	}
+	sliceType, ok := collectionType.Underlying().(*types.Slice)
+	if !ok || !validType(sliceType.Elem()) || !types.Comparable(sliceType.Elem()) {
+		return SuggestedFix{}, false
+	}
+	var qualifier types.Qualifier
+	if object := functionContext.Types.Uses[root]; object != nil && object.Pkg() != nil {
+		qualifier = types.RelativeTo(object.Pkg())
+	}
+
+	set := functionContext.freeName(setName(collection), outer.Pos())
+	build := fmt.Sprintf("%s := make(map[%s]bool, len(%s))\nfor _, element := range %s {\n%s[element] = true\n}",
+		set, types.TypeString(sliceType.Elem(), qualifier), nodeString(collection), nodeString(collection), set)
+	textEdits := []TextEdit{
+		functionContext.insertBefore(outer, build),
+		functionContext.replace(call, fmt.Sprintf("%s[%s]", set, nodeString(call.Args[1]))),
+	}
+	if removal, ok := functionContext.removeImport(call.Fun); ok {
+		textEdits = append(textEdits, removal)
+	}
+	return SuggestedFix{
+		Message:   fmt.Sprintf("look %s up in a set built before the loop", nodeString(call.Args[1])),
+		TextEdits: textEdits,
+	}, true
+}
+
+// setName names the set of a collection after it, itemsSet for items
+func setName(collection ast.Expr) string {
+	if selector, ok := collection.(*ast.SelectorExpr); ok {
+		return selector.Sel.Name + "Set"
+	}
+	return nodeString(collection) + "Set"
+}
+
+// validType tells whether the type checker could work a type out, types from packages that
+// were not imported are not
+func validType(typ types.Type) bool {
+	valid := true
+	var check func(typ types.Type)
+	check = func(typ types.Type) {
+		switch t := typ.(type) {
+		case *types.Basic:
+			if t.Kind() == types.Invalid {
+				valid = false
+			}
+		case *types.Pointer:
+			check(t.Elem())
+		case *types.Array:
+			check(t.Elem())
+		case *types.Struct:
+			for i := range t.NumFields() {
+				check(t.Field(i).Type())
+			}
+		}
+	}
+	check(typ)
+	return valid
+}
+
+// checkSearchLoop reports a loop, just entered, that searches a collection for an element of
+// the loop around it: a hand-rolled slices.Contains
+func (functionContext *FunctionContext) checkSearchLoop(loop ast.Stmt, body *ast.BlockStmt, variables []string, collection ast.Expr) {
+	if len(functionContext.loops) < 2 || !searchesFor(body, variables) {
+		return
+	}
+	functionContext.reportSearch(loop, functionContext.loops[len(functionContext.loops)-2], collection, functionContext.Inputs)
+}
+
+func (functionContext *FunctionContext) reportSearch(search ast.Node, outer ast.Stmt, collection ast.Expr, factors []Factor, fixes ...SuggestedFix) {
+	outerLine := 0
+	if functionContext.FileSet != nil {
+		outerLine = functionContext.FileSet.Position(outer.Pos()).Line
+	}
+	total := FormatTerms([]Term{normaliseTerm(factors)})
+	functionContext.Report(search.Pos(), RuleLinearSearch, fmt.Sprintf("%s is searched linearly on every iteration of the loop at line %d, %s in total, build a map or set of it before that loop", nodeString(collection), outerLine, total), fixes...)
+}
+
+// searchesFor tells whether a loop body looks for a match, an if comparing an element of the
+// loop to something else and leaving the loop when they are equal
+func searchesFor(body *ast.BlockStmt, variables []string) bool {
+	for _, stmt := range body.List {
+		ifStmt, ok := stmt.(*ast.IfStmt)
+		if !ok {
+			continue
+		}
+		cond, ok := ifStmt.Cond.(*ast.BinaryExpr)
+		if !ok || cond.Op != token.EQL || mentions(cond.X, variables) == mentions(cond.Y, variables) {
+			continue
+		}
+		for _, inner := range ifStmt.Body.List {
+			switch leave := inner.(type) {
+			case *ast.ReturnStmt:
+				return true
+			case *ast.BranchStmt:
+				if leave.Tok == token.BREAK {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// mentions tells whether an expression uses one of the variables
+func mentions(expr ast.Expr, variables []string) bool {
+	found := false
+	ast.Inspect(expr, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok && slices.Contains(variables, ident.Name) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// loopVariables are the variables a range statement or the init of a for statement declares
+func loopVariables(stmt ast.Stmt) []string {
+	var exprs []ast.Expr
+	switch loop := stmt.(type) {
+	case *ast.RangeStmt:
+		exprs = []ast.Expr{loop.Key, loop.Value}
+	case *ast.AssignStmt:
+		exprs = loop.Lhs
+	}
+	var variables []string
+	for _, expr := range exprs {
+		if ident, ok := expr.(*ast.Ident); ok && ident.Name != "_" {
+			variables = append(variables, ident.Name)
+		}
+	}
+	return variables
+}
+
+// searchedCollection is the collection a for loop bounded by len(bs) walks through
+func searchedCollection(bound ast.Expr) ast.Expr {
+	if call, ok := bound.(*ast.CallExpr); ok && CalleeName(call) == "len" && len(call.Args) == 1 {
+		return call.Args[0]
+	}
+	return bound
+}
@@ -0,0 +1,121 @@
+package analyser
+
+import (
+	"go/ast"
+	"slices"
+)
+
+const (
+	RuleLimit  = "limit"
+	RuleSyntax = "syntax"
+	RuleNoBody = "no-body"
+)
+
+// Options configure a run of the analyser, the zero value analyses every function of the files
+type Options struct {
+	// Functions are the names of the functions analysed, all of them when empty. A name matches
+	// functions named so in another case only when no function of the run is named exactly so
+	Functions []string
+	// CostModels give the complexity of calls to code outside of the analysed files
+	CostModels []CostModel
+	// Analysers are the names of registered analysers that also visit every function
+	Analysers []string
+	// Rules are the diagnostics reported, like RuleExpect or RuleThreshold, all of them when empty
+	Rules      []string
+	Thresholds Thresholds
+	Limits     Limits
+	Detail     Detail
+	// Cache keeps results between runs, functions are only analysed again when they or the
+	// functions they call changed
+	Cache Cache
+	// Jobs is how many files and functions are analysed concurrently, GOMAXPROCS when zero
+	Jobs int
+	// OnFunction receives every selected function one at a time in the order of the files and
+	// of the source, as soon as it and the functions before it are analysed. Report.Functions
+	// stays empty then, so that big runs can be written out as they go
+	OnFunction func(FunctionInfo)
+	// exact are the names of Functions some function of the run is named exactly
+	exact map[string]bool
+}
+
+// Limits bound how much work a run does, zero means unlimited
+type Limits struct {
+	// MaxFunctions stops the run once that many functions were analysed, the report is truncated
+	MaxFunctions int
+	// MaxFileSize skips files larger than that many bytes with a limit diagnostic
+	MaxFileSize int64
+}
+
+// Detail is how much of every function is kept in the report
+type Detail int
+
+const (
+	// DetailFull keeps the symbol table and call sites of every function
+	DetailFull Detail = iota
+	// DetailSummary only keeps where a function is, its complexity, directives and diagnostics
+	DetailSummary
+)
+
+// Report is the result of a run: the analysed functions and every diagnostic raised on them
+type Report struct {
+	Functions   []FunctionInfo
+	Diagnostics []Diagnostic
+	// Truncated is set when Limits.MaxFunctions stopped the run before every function was analysed
+	Truncated bool
+	// analysed counts the selected functions, whether they were kept or passed to OnFunction
+	analysed int
+}
+
+func (report *Report) addDiagnostics(options Options, diagnostics ...Diagnostic) {
+	report.Diagnostics = append(report.Diagnostics, options.enabled(diagnostics)...)
+}
+
+// finish drops what the options leave out of a function's result
+func (options Options) finish(functionInfo FunctionInfo) FunctionInfo {
+	functionInfo.Diagnostics = options.enabled(functionInfo.Diagnostics)
+	if options.Detail == DetailSummary {
+		functionInfo.SymbolTable = SymbolTable{}
+		functionInfo.Calls = nil
+	}
+	return functionInfo
+}
+
+func (options Options) selects(decl *ast.FuncDecl) bool {
+	return len(options.Functions) == 0 || slices.ContainsFunc(options.Functions, func(name string) bool {
+		if options.exact[name] {
+			return decl.Name.Name == name
+		}
+		return isFunctionName(decl, name)
+	})
+}
+
+// matchExactly notes which of Functions name a function of the files exactly, those no longer
+// match functions named so in another case
+func (options Options) matchExactly(files []parsedFile) Options {
+	options.exact = map[string]bool{}
+	for _, file := range files {
+		if file.file == nil {
+			continue
+		}
+		for _, declaration := range file.file.Decls {
+			if decl, ok := declaration.(*ast.FuncDecl); ok && decl.Name != nil && slices.Contains(options.Functions, decl.Name.Name) {
+				options.exact[decl.Name.Name] = true
+			}
+		}
+	}
+	return options
+}
+
+// enabled filters out the diagnostics of rules that are not enabled
+func (options Options) enabled(diagnostics []Diagnostic) []Diagnostic {
+	if len(options.Rules) == 0 {
+		return diagnostics
+	}
+	var kept []Diagnostic
+	for _, diagnostic := range diagnostics {
+		if slices.Contains(options.Rules, diagnostic.Rule) {
+			kept = append(kept, diagnostic)
+		}
+	}
+	return kept
+}
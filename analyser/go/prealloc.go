@@ -0,0 +1,186 @@
+package analyser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+const RulePrealloc = "prealloc"
+
+// checkPrealloc finds slices grown by an append on every iteration of a loop whose number of
+// iterations is known before it starts, `for _, item := range items { out = append(out, f(item)) }`.
+// Growing them reallocates and copies log n times, make([]T, 0, len(items)) allocates once.
+// Slices declared empty are fixed, nil slices only reported as making them empty would show,
+// e.g. in JSON
+func (functionContext *FunctionContext) checkPrealloc(loop ast.Stmt, body *ast.BlockStmt) {
+	if functionContext.Types == nil || functionContext.FileSet == nil {
+		return
+	}
+	capacity, bound, ok := functionContext.iterations(loop)
+	if !ok {
+		return
+	}
+	line := functionContext.FileSet.Position(loop.Pos()).Line
+	for _, stmt := range body.List {
+		target, ok := appendTarget(stmt)
+		if !ok {
+			continue
+		}
+		object := functionContext.Types.Uses[target]
+		if object == nil {
+			continue
+		}
+		decl, elementType, fixable := functionContext.declaration(object, loop)
+		if decl == nil {
+			continue
+		}
+		preallocation := fmt.Sprintf("make([]%s, 0, %s)", elementType, capacity)
+		var fixes []SuggestedFix
+		// the capacity is computed where the slice is declared, which may be before what it reads
+		if fixable && functionContext.visibleAt(bound, decl.Pos()) {
+			fixes = append(fixes, SuggestedFix{
+				Message:   fmt.Sprintf("preallocate %s with %s", target.Name, preallocation),
+				TextEdits: []TextEdit{functionContext.replace(decl, target.Name+" := "+preallocation)},
+			})
+		}
+		functionContext.Report(stmt.Pos(), RulePrealloc, fmt.Sprintf("%s grows by append on every iteration of the loop at line %d, preallocate it with %s", target.Name, line, preallocation), fixes...)
+	}
+}
+
+// iterations is how many times a loop runs, when it is known before it starts: len(items)
+// for a range over a slice, a map or a string, n for a range over an integer or a counting loop.
+// The expression it is computed from is returned along with it
+func (functionContext *FunctionContext) iterations(loop ast.Stmt) (string, ast.Expr, bool) {
+	switch stmt := loop.(type) {
+	case *ast.RangeStmt:
+		if !stable(stmt.X) {
+			return "", nil, false
+		}
+		rangeType := functionContext.Types.TypeOf(stmt.X)
+		if rangeType == nil {
+			return "", nil, false
+		}
+		switch underlying := rangeType.Underlying().(type) {
+		case *types.Slice, *types.Map, *types.Array:
+			return "len(" + nodeString(stmt.X) + ")", stmt.X, true
+		case *types.Basic:
+			if underlying.Info()&types.IsString != 0 {
+				return "len(" + nodeString(stmt.X) + ")", stmt.X, true
+			}
+			if underlying.Info()&types.IsInteger != 0 {
+				return nodeString(stmt.X), stmt.X, true
+			}
+		}
+	case *ast.ForStmt:
+		init, ok := stmt.Init.(*ast.AssignStmt)
+		if !ok || len(init.Lhs) != 1 || len(init.Rhs) != 1 || nodeString(init.Rhs[0]) != "0" {
+			return "", nil, false
+		}
+		cond, ok := stmt.Cond.(*ast.BinaryExpr)
+		post, postOk := stmt.Post.(*ast.IncDecStmt)
+		if !ok || !postOk || cond.Op != token.LSS || post.Tok != token.INC ||
+			nodeString(cond.X) != nodeString(init.Lhs[0]) || nodeString(post.X) != nodeString(init.Lhs[0]) {
+			return "", nil, false
+		}
+		if call, ok := cond.Y.(*ast.CallExpr); ok && CalleeName(call) == "len" && len(call.Args) == 1 && stable(call.Args[0]) {
+			return nodeString(cond.Y), cond.Y, true
+		}
+		if stable(cond.Y) {
+			return nodeString(cond.Y), cond.Y, true
+		}
+	}
+	return "", nil, false
+}
+
+// stable tells whether an expression reads a variable or a field, without calling anything
+func stable(expr ast.Expr) bool {
+	switch exp := expr.(type) {
+	case *ast.Ident:
+		return true
+	case *ast.SelectorExpr:
+		return stable(exp.X)
+	}
+	return false
+}
+
+// appendTarget is the slice of `out = append(out, x)`, the one element appends
+func appendTarget(stmt ast.Stmt) (*ast.Ident, bool) {
+	assignStmt, ok := stmt.(*ast.AssignStmt)
+	if !ok || assignStmt.Tok != token.ASSIGN || len(assignStmt.Lhs) != 1 || len(assignStmt.Rhs) != 1 {
+		return nil, false
+	}
+	target, ok := assignStmt.Lhs[0].(*ast.Ident)
+	call, callOk := assignStmt.Rhs[0].(*ast.CallExpr)
+	if !ok || !callOk || CalleeName(call) != "append" || len(call.Args) != 2 || call.Ellipsis.IsValid() {
+		return nil, false
+	}
+	if grown, ok := call.Args[0].(*ast.Ident); !ok || grown.Name != target.Name {
+		return nil, false
+	}
+	return target, true
+}
+
+// declaration finds the statement declaring a slice empty before a loop, `var out []T`,
+// `out := []T{}` or `out := make([]T, 0)`, when nothing else is assigned to it until the loop.
+// The last ones can be replaced by a preallocation without changing the program
+func (functionContext *FunctionContext) declaration(object types.Object, loop ast.Stmt) (ast.Stmt, string, bool) {
+	var decl ast.Stmt
+	var elementType string
+	fixable := false
+	reassigned := false
+	ast.Inspect(functionContext.body, func(node ast.Node) bool {
+		switch stmt := node.(type) {
+		case *ast.DeclStmt:
+			genDecl, ok := stmt.Decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.VAR || len(genDecl.Specs) != 1 {
+				return true
+			}
+			valueSpec, ok := genDecl.Specs[0].(*ast.ValueSpec)
+			if !ok || len(valueSpec.Names) != 1 || valueSpec.Names[0].Pos() != object.Pos() || len(valueSpec.Values) != 0 {
+				return true
+			}
+			if sliceType, ok := valueSpec.Type.(*ast.ArrayType); ok && sliceType.Len == nil {
+				decl, elementType = stmt, nodeString(sliceType.Elt)
+			}
+		case *ast.AssignStmt:
+			for _, lhs := range stmt.Lhs {
+				ident, ok := lhs.(*ast.Ident)
+				if !ok {
+					continue
+				}
+				if stmt.Tok == token.DEFINE && ident.Pos() == object.Pos() && len(stmt.Lhs) == 1 && len(stmt.Rhs) == 1 {
+					if sliceType, ok := emptySlice(stmt.Rhs[0]); ok {
+						decl, elementType, fixable = stmt, nodeString(sliceType.Elt), true
+					}
+				} else if functionContext.Types.Uses[ident] == object && stmt.Pos() < loop.Pos() {
+					reassigned = true
+				}
+			}
+		}
+		return true
+	})
+	if decl == nil || reassigned || decl.End() > loop.Pos() {
+		return nil, "", false
+	}
+	return decl, elementType, fixable
+}
+
+// emptySlice matches []T{} and make([]T, 0)
+func emptySlice(expr ast.Expr) (*ast.ArrayType, bool) {
+	switch exp := expr.(type) {
+	case *ast.CompositeLit:
+		if sliceType, ok := exp.Type.(*ast.ArrayType); ok && sliceType.Len == nil && len(exp.Elts) == 0 {
+			return sliceType, true
+		}
+	case *ast.CallExpr:
+		if CalleeName(exp) != "make" || len(exp.Args) != 2 || nodeString(exp.Args[1]) != "0" {
+			return nil, false
+		}
+		if sliceType, ok := exp.Args[0].(*ast.ArrayType); ok && sliceType.Len == nil {
+			return sliceType, true
+		}
+	}
+	return nil, false
+}
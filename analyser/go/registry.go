@@ -0,0 +1,126 @@
+package analyser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	registryLock sync.RWMutex
+	registry     = map[string]func() Analyser{}
+)
+
+// Register makes an analyser available by name to Options.Analysers, usually from an init
+// function. Enabled analysers share one walk over every function with the complexity
+// analyser. The factory is called for every function, so analysers may keep state while
+// they visit one. Register panics when the name is taken, like database/sql.Register
+func Register(name string, factory func() Analyser) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if factory == nil {
+		panic("analyser: Register factory is nil")
+	}
+	if _, taken := registry[name]; taken {
+		panic("analyser: Register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// Analysers lists the names of the registered analysers in alphabetical order
+func Analysers() []string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	return registeredNames()
+}
+
+func registeredNames() []string {
+	var names []string
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// factories looks up the enabled analysers, the complexity analyser first and the others in
+// the order they were enabled in
+func (options Options) factories() ([]func() Analyser, error) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	factories := []func() Analyser{registry[ComplexityAnalyser]}
+	for _, name := range options.Analysers {
+		factory, ok := registry[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown analyser %q, registered analysers are %s", name, strings.Join(registeredNames(), ", "))
+		}
+		if name != ComplexityAnalyser {
+			factories = append(factories, factory)
+		}
+	}
+	return factories, nil
+}
+
+// Leaver is implemented by analysers that keep state for the nodes below a node, like the
+// complexity analyser entering a loop with its body. Walk calls Leave after the node's children
+type Leaver interface {
+	Leave(node ast.Node, functionContext *FunctionContext)
+}
+
+// Walk walks every node of a function body once, depth first, and lets each analyser visit
+// it in order. FunctionContext.Loops holds the loops around the node being visited
+func Walk(body *ast.BlockStmt, functionContext *FunctionContext, analysers ...Analyser) {
+	if body == nil {
+		return
+	}
+	var path []ast.Node
+	ast.Inspect(body, func(node ast.Node) bool {
+		if node == nil {
+			exited := path[len(path)-1]
+			path = path[:len(path)-1]
+			for _, analyser := range analysers {
+				if leaver, ok := analyser.(Leaver); ok {
+					leaver.Leave(exited, functionContext)
+				}
+			}
+			if len(functionContext.Loops) > 0 && functionContext.Loops[len(functionContext.Loops)-1] == exited {
+				functionContext.Loops = functionContext.Loops[:len(functionContext.Loops)-1]
+			}
+			return true
+		}
+		for _, analyser := range analysers {
+			analyser.Visit(node, functionContext)
+		}
+		path = append(path, node)
+		switch node.(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+			functionContext.Loops = append(functionContext.Loops, node.(ast.Stmt))
+		}
+		return true
+	})
+}
+
+// Report adds a diagnostic at a position of the function, for analysers to raise findings
+func (functionContext *FunctionContext) Report(pos token.Pos, rule string, message string, fixes ...SuggestedFix) {
+	diagnostic := Diagnostic{File: functionContext.File, Function: functionContext.Name, Rule: rule, Message: message, Fixes: fixes}
+	if functionContext.FileSet != nil {
+		diagnostic.Line = functionContext.FileSet.Position(pos).Line
+	}
+	functionContext.Diagnostics = append(functionContext.Diagnostics, diagnostic)
+}
+
+// AddMetric adds to a named metric of the function, metrics start at zero
+func (functionContext *FunctionContext) AddMetric(name string, value float64) {
+	if functionContext.Metrics == nil {
+		functionContext.Metrics = map[string]float64{}
+	}
+	functionContext.Metrics[name] += value
+}
+
+// InLoop tells whether the node being visited is inside a loop of the function
+func (functionContext *FunctionContext) InLoop() bool {
+	return len(functionContext.Loops) > 0
+}
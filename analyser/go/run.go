@@ -0,0 +1,613 @@
+package analyser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// parsedFile is a file of a run. Diagnostics explain why a file was skipped, when file is
+// nil, or where its syntax errors are
+type parsedFile struct {
+	fileContext *FileContext
+	file        *ast.File
+	diagnostics []Diagnostic
+}
+
+// unit is a function of a run, callees are the units of the same package it calls
+type unit struct {
+	decl        *ast.FuncDecl
+	fileContext *FileContext
+	selected    bool
+	callees     []int
+	result      FunctionInfo
+}
+
+// Run analyses Go files with the options. Files are parsed and functions analysed by a pool
+// of Options.Jobs workers, a function only after the functions of its package it calls so
+// that their cost is accounted for. The report lists functions in the order of the files
+// and of the source, however the work was scheduled.
+// Run stops once ctx is done and returns ctx.Err(), so big analyses can be cancelled or
+// time-boxed with context.WithTimeout
+func Run(ctx context.Context, filePaths []string, options Options) (*Report, error) {
+	// one file set for all files, so that the files of a package are type checked together
+	fset := token.NewFileSet()
+	files := make([]parsedFile, len(filePaths))
+	err := forEach(ctx, options.jobs(), len(filePaths), func(i int) error {
+		var err error
+		files[i], err = parseFile(fset, filePaths[i], options.Limits)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := analyseFiles(ctx, files, options)
+	if err != nil {
+		return nil, err
+	}
+	if len(options.Functions) > 0 && report.analysed == 0 {
+		return nil, errors.New("no such function in the analysed files")
+	}
+	return report, nil
+}
+
+// RunFile analyses a file that was already parsed with the options, like Run
+func RunFile(ctx context.Context, fset *token.FileSet, file *ast.File, options Options) (*Report, error) {
+	fileContext := newFileContext(fset, file, fset.Position(file.Pos()).Filename)
+	report, err := analyseFiles(ctx, []parsedFile{{fileContext: fileContext, file: file}}, options)
+	if err != nil {
+		return nil, err
+	}
+	if len(options.Functions) > 0 && report.analysed == 0 {
+		return nil, errors.New("no such function in this file")
+	}
+	return report, nil
+}
+
+// RunSource analyses Go source that is already in memory with the options, like Run.
+// filePath is only used for positions
+func RunSource(ctx context.Context, filePath string, src []byte, options Options) (*Report, error) {
+	report, err := analyseFiles(ctx, []parsedFile{parseSource(token.NewFileSet(), filePath, src)}, options)
+	if err != nil {
+		return nil, err
+	}
+	if len(options.Functions) > 0 && report.analysed == 0 {
+		return nil, errors.New("no such function in this file")
+	}
+	return report, nil
+}
+
+// Source is Go source that is already in memory, Path is only used for positions
+type Source struct {
+	Path string
+	Src  []byte
+}
+
+// RunSources analyses Go sources that are already in memory with the options, like Run, the
+// sources of a directory forming one package, e.g. the files of a package as of a revision
+func RunSources(ctx context.Context, sources []Source, options Options) (*Report, error) {
+	fset := token.NewFileSet()
+	files := make([]parsedFile, len(sources))
+	for i, source := range sources {
+		if skipped, ok := oversized(source.Path, int64(len(source.Src)), options.Limits); ok {
+			files[i] = skipped
+			continue
+		}
+		files[i] = parseSource(fset, source.Path, source.Src)
+	}
+
+	report, err := analyseFiles(ctx, files, options)
+	if err != nil {
+		return nil, err
+	}
+	if len(options.Functions) > 0 && report.analysed == 0 {
+		return nil, errors.New("no such function in the analysed files")
+	}
+	return report, nil
+}
+
+func (options Options) jobs() int {
+	if options.Jobs > 0 {
+		return options.Jobs
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+func parseFile(fset *token.FileSet, filePath string, limits Limits) (parsedFile, error) {
+	if limits.MaxFileSize > 0 {
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return parsedFile{}, err
+		}
+		if skipped, ok := oversized(filePath, info.Size(), limits); ok {
+			return skipped, nil
+		}
+	}
+	src, IOError := os.ReadFile(filePath)
+	if IOError != nil {
+		return parsedFile{}, IOError
+	}
+	return parseSource(fset, filePath, src), nil
+}
+
+// oversized tells whether a file is beyond Limits.MaxFileSize, the skipped file then explains
+// why it was not analysed
+func oversized(filePath string, size int64, limits Limits) (parsedFile, bool) {
+	if limits.MaxFileSize <= 0 || size <= limits.MaxFileSize {
+		return parsedFile{}, false
+	}
+	return parsedFile{diagnostics: []Diagnostic{{
+		File:    filePath,
+		Line:    1,
+		Rule:    RuleLimit,
+		Message: fmt.Sprintf("skipped, the file is larger than %d bytes", limits.MaxFileSize),
+	}}}, true
+}
+
+// parseSource parses a file, syntax errors become diagnostics and the functions that parsed
+// are still analysed
+func parseSource(fset *token.FileSet, filePath string, src []byte) parsedFile {
+	file, err := parser.ParseFile(fset, filePath, src, parser.ParseComments|parser.AllErrors)
+	var parsed parsedFile
+	if file != nil {
+		parsed = parsedFile{fileContext: newFileContext(fset, file, filePath), file: file}
+	}
+	var syntaxErrors scanner.ErrorList
+	if errors.As(err, &syntaxErrors) {
+		for _, syntaxError := range syntaxErrors {
+			parsed.diagnostics = append(parsed.diagnostics, Diagnostic{
+				File:    filePath,
+				Line:    syntaxError.Pos.Line,
+				Rule:    RuleSyntax,
+				Message: syntaxError.Msg,
+			})
+		}
+	} else if err != nil {
+		parsed.diagnostics = append(parsed.diagnostics, Diagnostic{File: filePath, Line: 1, Rule: RuleSyntax, Message: err.Error()})
+	}
+	return parsed
+}
+
+func newFileContext(fset *token.FileSet, file *ast.File, filePath string) *FileContext {
+	var fileContext FileContext = GetFileContext(file)
+	fileContext.FileSet = fset
+	fileContext.FilePath = filePath
+	return &fileContext
+}
+
+// hasSyntaxError tells whether a syntax error was found between two lines, a function around
+// one is not analysed as its partial syntax tree would give a wrong complexity
+func (parsed parsedFile) hasSyntaxError(from int, to int) bool {
+	for _, diagnostic := range parsed.diagnostics {
+		if diagnostic.Rule == RuleSyntax && diagnostic.Line >= from && diagnostic.Line <= to {
+			return true
+		}
+	}
+	return false
+}
+
+// bodilessDiagnostics note the selected functions without a body, implemented in assembly or
+// linked from elsewhere, as they are not analysed
+func (parsed parsedFile) bodilessDiagnostics(options Options) []Diagnostic {
+	if parsed.file == nil {
+		return nil
+	}
+	var diagnostics []Diagnostic
+	for _, declaration := range parsed.file.Decls {
+		if decl, ok := declaration.(*ast.FuncDecl); ok && decl.Body == nil && decl.Name != nil && options.selects(decl) {
+			diagnostics = append(diagnostics, Diagnostic{
+				File:    parsed.fileContext.FilePath,
+				Line:    parsed.fileContext.FileSet.Position(decl.Pos()).Line,
+				Rule:    RuleNoBody,
+				Message: fmt.Sprintf("%s has no body, it is implemented in assembly or linked from elsewhere and was not analysed", decl.Name.Name),
+			})
+		}
+	}
+	return diagnostics
+}
+
+func analyseFiles(ctx context.Context, files []parsedFile, options Options) (*Report, error) {
+	report := &Report{}
+	options = options.matchExactly(files)
+	factories, err := options.factories()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := typeCheck(ctx, files, options.jobs()); err != nil {
+		return nil, err
+	}
+	units, truncated := collectUnits(files, options)
+	report.Truncated = truncated
+
+	session := &session{units: units, options: options, costModel: CostModel{}.Merge(options.CostModels...), factories: factories}
+	if options.Cache != nil {
+		session.optionsKey = optionsKey(session.costModel, options.Analysers)
+		session.typesKeys = typesKeys(files)
+	}
+	components := callGraphComponents(units)
+	// functions are streamed in source order, each once it and every function before it is done
+	var streaming sync.Mutex
+	done := make([]bool, len(units))
+	streamed := 0
+	err = forEachInOrder(ctx, options.jobs(), units, components, func(component []int) {
+		inComponent := map[int]bool{}
+		for _, id := range component {
+			inComponent[id] = true
+		}
+		for _, id := range component {
+			units[id].result = session.analyse(id, inComponent)
+		}
+		if options.OnFunction == nil {
+			return
+		}
+		streaming.Lock()
+		defer streaming.Unlock()
+		for _, id := range component {
+			done[id] = true
+		}
+		for ; streamed < len(units) && done[streamed]; streamed++ {
+			if units[streamed].selected {
+				options.OnFunction(options.finish(units[streamed].result))
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fileIndex := 0
+	for _, file := range files {
+		report.addDiagnostics(options, file.diagnostics...)
+		report.addDiagnostics(options, file.bodilessDiagnostics(options)...)
+		for ; fileIndex < len(units) && units[fileIndex].fileContext == file.fileContext; fileIndex++ {
+			unit := &units[fileIndex]
+			if !unit.selected {
+				continue
+			}
+			functionInfo := options.finish(unit.result)
+			report.analysed++
+			if options.OnFunction == nil {
+				report.Functions = append(report.Functions, functionInfo)
+			}
+			report.addDiagnostics(options, functionInfo.Diagnostics...)
+			report.addDiagnostics(options, options.Thresholds.Check(functionInfo)...)
+		}
+	}
+	return report, nil
+}
+
+// collectUnits lists the functions of the files in source order. Functions that are not
+// selected, or beyond Limits.MaxFunctions, are only kept when a selected function calls them
+func collectUnits(files []parsedFile, options Options) ([]unit, bool) {
+	var units []unit
+	byPackage := map[string]int{}
+	for _, file := range files {
+		if file.file == nil {
+			continue
+		}
+		fset := file.fileContext.FileSet
+		for _, declaration := range file.file.Decls {
+			// functions without a body are implemented in assembly or linked from elsewhere
+			if decl, ok := declaration.(*ast.FuncDecl); ok && decl.Body != nil && decl.Name != nil &&
+				!file.hasSyntaxError(fset.Position(decl.Pos()).Line, fset.Position(decl.End()).Line) {
+				if decl.Recv == nil {
+					byPackage[packageKey(file.fileContext)+"."+decl.Name.Name] = len(units)
+				}
+				units = append(units, unit{decl: decl, fileContext: file.fileContext, selected: options.selects(decl)})
+			}
+		}
+	}
+
+	for id := range units {
+		seen := map[int]bool{}
+		for _, call := range GetCalls(units[id].decl, nil) {
+			callee, ok := byPackage[packageKey(units[id].fileContext)+"."+call.Name]
+			if ok && callee != id && !seen[callee] {
+				seen[callee] = true
+				units[id].callees = append(units[id].callees, callee)
+			}
+		}
+	}
+
+	// keep the selected functions within the limit and everything they depend on
+	needed := make([]bool, len(units))
+	var need func(id int)
+	need = func(id int) {
+		if needed[id] {
+			return
+		}
+		needed[id] = true
+		for _, callee := range units[id].callees {
+			need(callee)
+		}
+	}
+	selected, truncated := 0, false
+	for id := range units {
+		if !units[id].selected {
+			continue
+		}
+		if options.Limits.MaxFunctions > 0 && selected >= options.Limits.MaxFunctions {
+			units[id].selected = false
+			truncated = true
+			continue
+		}
+		selected++
+		need(id)
+	}
+	return pruneUnits(units, needed), truncated
+}
+
+// pruneUnits drops the units that are not needed and renumbers the callees of the others
+func pruneUnits(units []unit, needed []bool) []unit {
+	renumbered := make([]int, len(units))
+	var kept []unit
+	for id := range units {
+		if needed[id] {
+			renumbered[id] = len(kept)
+			kept = append(kept, units[id])
+		}
+	}
+	for id := range kept {
+		for i, callee := range kept[id].callees {
+			kept[id].callees[i] = renumbered[callee]
+		}
+	}
+	return kept
+}
+
+// typeCheck type checks the files of every package for the detectors that need types, like
+// the string concatenation one. Imports are not followed, so what depends on other packages
+// is left without a type and detectors stay silent about it
+func typeCheck(ctx context.Context, files []parsedFile, jobs int) error {
+	var keys []string
+	packages := map[string][]*ast.File{}
+	contexts := map[string][]*FileContext{}
+	for _, file := range files {
+		if file.file == nil {
+			continue
+		}
+		key := packageKey(file.fileContext)
+		if _, ok := packages[key]; !ok {
+			keys = append(keys, key)
+		}
+		packages[key] = append(packages[key], file.file)
+		contexts[key] = append(contexts[key], file.fileContext)
+	}
+	return forEach(ctx, jobs, len(keys), func(i int) error {
+		info := &types.Info{
+			Types:  map[ast.Expr]types.TypeAndValue{},
+			Defs:   map[*ast.Ident]types.Object{},
+			Uses:   map[*ast.Ident]types.Object{},
+			Scopes: map[ast.Node]*types.Scope{},
+		}
+		config := types.Config{Error: func(error) {}}
+		fileContexts := contexts[keys[i]]
+		config.Check(fileContexts[0].Package, fileContexts[0].FileSet, packages[keys[i]], info)
+		for _, fileContext := range fileContexts {
+			fileContext.Types = info
+		}
+		return nil
+	})
+}
+
+// packageKey identifies the package of a file, the files of a directory form one package
+func packageKey(fileContext *FileContext) string {
+	return filepath.Dir(fileContext.FilePath) + ":" + fileContext.Package
+}
+
+// session is what the workers of a run share
+type session struct {
+	units      []unit
+	options    Options
+	costModel  CostModel
+	factories  []func() Analyser
+	optionsKey string
+	// typesKeys are the typesKeys of the packages of the run
+	typesKeys map[string]string
+}
+
+// analyse visits a function, calls to functions analysed before it cost what they cost.
+// Calls within the same recursive cycle are cut, like calls the analyser cannot see into
+func (session *session) analyse(id int, inComponent map[int]bool) FunctionInfo {
+	units := session.units
+	decl := units[id].decl
+	fileContext := units[id].fileContext
+	callees := map[string]int{}
+	for _, callee := range units[id].callees {
+		if !inComponent[callee] {
+			callees[units[callee].decl.Name.Name] = callee
+		}
+	}
+
+	cache := session.options.Cache
+	key, cacheable := "", false
+	if cache != nil {
+		key, cacheable = cacheKey(units, id, callees, session.optionsKey, session.typesKeys[packageKey(fileContext)])
+	}
+	if cacheable {
+		if cached, ok := cache.Get(key); ok {
+			return relocate(cached, fileContext, fileContext.FileSet.Position(decl.Pos()).Line)
+		}
+	}
+
+	functionContext := GetFunctionContext(decl, fileContext)
+	var analysers []Analyser
+	for _, factory := range session.factories {
+		analysers = append(analysers, factory())
+	}
+	complexity := analysers[0].(*TimeAndSpaceComplexityAnalyser)
+	complexity.CostModel = session.costModel
+	complexity.Callee = func(call *ast.CallExpr) (Complexity, bool) {
+		ident, ok := call.Fun.(*ast.Ident)
+		if !ok {
+			return Complexity{}, false
+		}
+		callee, ok := callees[ident.Name]
+		if !ok {
+			return Complexity{}, false
+		}
+		// a lower bound of the callee is only a lower bound of the call
+		if len(units[callee].result.Unresolved) > 0 {
+			functionContext.Unresolve(call, UnresolvedCall, "call to %s costs at least %s", ident.Name, FormatComplexity(units[callee].result.Complexity.Time))
+		}
+		return units[callee].result.Complexity, true
+	}
+	Walk(decl.Body, functionContext, analysers...)
+	functionInfo := ParseContextToInfo(functionContext)
+	if cacheable {
+		cache.Put(key, functionInfo)
+	}
+	return functionInfo
+}
+
+// callGraphComponents groups the units into the strongly connected components of the call
+// graph with Tarjan's algorithm, callees' components come before their callers'
+func callGraphComponents(units []unit) [][]int {
+	index := make([]int, len(units))
+	lowLink := make([]int, len(units))
+	onStack := make([]bool, len(units))
+	for id := range index {
+		index[id] = -1
+	}
+	var stack []int
+	var components [][]int
+	next := 0
+
+	var connect func(id int)
+	connect = func(id int) {
+		index[id], lowLink[id] = next, next
+		next++
+		stack = append(stack, id)
+		onStack[id] = true
+		for _, callee := range units[id].callees {
+			if index[callee] == -1 {
+				connect(callee)
+				lowLink[id] = min(lowLink[id], lowLink[callee])
+			} else if onStack[callee] {
+				lowLink[id] = min(lowLink[id], index[callee])
+			}
+		}
+		if lowLink[id] == index[id] {
+			var component []int
+			for {
+				member := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[member] = false
+				component = append(component, member)
+				if member == id {
+					break
+				}
+			}
+			components = append(components, component)
+		}
+	}
+	for id := range units {
+		if index[id] == -1 {
+			connect(id)
+		}
+	}
+	return components
+}
+
+// forEach runs work for 0..n-1 on a pool of workers, stopping at the first error
+func forEach(ctx context.Context, jobs int, n int, work func(i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	indexes := make(chan int)
+	var firstError error
+	var errorOnce sync.Once
+	var workers sync.WaitGroup
+	for range min(jobs, max(n, 1)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for i := range indexes {
+				if err := work(i); err != nil {
+					errorOnce.Do(func() { firstError = err })
+					cancel()
+				}
+			}
+		}()
+	}
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(indexes)
+	workers.Wait()
+	if firstError != nil {
+		return firstError
+	}
+	return ctx.Err()
+}
+
+// forEachInOrder runs work for the components of the call graph on a pool of workers,
+// a component only once every component it calls into is done
+func forEachInOrder(ctx context.Context, jobs int, units []unit, components [][]int, work func(component []int)) error {
+	componentOf := make([]int, len(units))
+	for c, component := range components {
+		for _, id := range component {
+			componentOf[id] = c
+		}
+	}
+	pending := make([]int, len(components))
+	callers := make([][]int, len(components))
+	for c, component := range components {
+		seen := map[int]bool{}
+		for _, id := range component {
+			for _, callee := range units[id].callees {
+				if d := componentOf[callee]; d != c && !seen[d] {
+					seen[d] = true
+					pending[c]++
+					callers[d] = append(callers[d], c)
+				}
+			}
+		}
+	}
+
+	ready := make(chan int, len(components))
+	done := make(chan int)
+	for c := range components {
+		if pending[c] == 0 {
+			ready <- c
+		}
+	}
+	var workers sync.WaitGroup
+	for range min(jobs, max(len(components), 1)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for c := range ready {
+				// once cancelled the remaining components are only drained
+				if ctx.Err() == nil {
+					work(components[c])
+				}
+				done <- c
+			}
+		}()
+	}
+	for range components {
+		c := <-done
+		for _, caller := range callers[c] {
+			if pending[caller]--; pending[caller] == 0 {
+				ready <- caller
+			}
+		}
+	}
+	close(ready)
+	workers.Wait()
+	return ctx.Err()
+}
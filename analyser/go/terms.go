@@ -0,0 +1,208 @@
+package analyser
+
+import (
+	"go/ast"
+	"slices"
+	"strings"
+)
+
+// Factor is the part of a time complexity term driven by one input, like len(users) in
+// O(len(users) · limit). Index is the complexity index it contributes, 1 for linear
+type Factor struct {
+	Input string
+	// Param is the parameter the input is derived from, empty when it is not a parameter
+	Param string
+	Index float32
+}
+
+// Term is a product of factors, the time complexity of a function is the sum of its terms
+type Term []Factor
+
+// FormatTerms names the inputs driving a time complexity, e.g. "O(len(users) · limit)" or
+// "O(|m| + n^2)" when several paths through the function are equally expensive
+func FormatTerms(terms []Term) string {
+	if len(terms) == 0 {
+		return "O(1)"
+	}
+	var sums []string
+	for _, term := range terms {
+		var products []string
+		for _, factor := range term {
+			products = append(products, formatFactor(factor))
+		}
+		sums = append(sums, strings.Join(products, " · "))
+	}
+	return "O(" + strings.Join(sums, " + ") + ")"
+}
+
+// formatFactor writes a factor like FormatComplexity with n replaced by the input
+func formatFactor(factor Factor) string {
+	growth := strings.TrimSuffix(strings.TrimPrefix(FormatComplexity(factor.Index), "O("), ")")
+	return strings.ReplaceAll(growth, "n", factor.Input)
+}
+
+// inputFactor names a parameter after the size that drives loops over it: len(items) for
+// slices, strings and channels, |m| for maps and the parameter itself for numbers
+func (functionContext *FunctionContext) inputFactor(name string) Factor {
+	factor := Factor{Input: name, Param: name, Index: 1}
+	switch paramType := functionContext.ParamTypes[name].(type) {
+	case *ast.MapType:
+		factor.Input = "|" + name + "|"
+	case *ast.ArrayType, *ast.Ellipsis, *ast.ChanType:
+		factor.Input = "len(" + name + ")"
+	case *ast.Ident:
+		if paramType.Name == "string" {
+			factor.Input = "len(" + name + ")"
+		}
+	}
+	return factor
+}
+
+// driverFactor finds the parameter an expression like i < len(items)-1 depends on
+func (functionContext *FunctionContext) driverFactor(expr ast.Expr) (Factor, bool) {
+	switch exp := expr.(type) {
+	case *ast.Ident:
+		if IsParam(exp.Name, &functionContext.SymbolTable) {
+			return functionContext.inputFactor(exp.Name), true
+		}
+	case *ast.CallExpr:
+		if funIdent, ok := exp.Fun.(*ast.Ident); ok && funIdent.Name == "len" && len(exp.Args) == 1 {
+			if argIdent, ok := exp.Args[0].(*ast.Ident); ok && IsParam(argIdent.Name, &functionContext.SymbolTable) {
+				return Factor{Input: "len(" + argIdent.Name + ")", Param: argIdent.Name, Index: 1}, true
+			}
+		}
+		for _, arg := range exp.Args {
+			if factor, ok := functionContext.driverFactor(arg); ok {
+				return factor, true
+			}
+		}
+	case *ast.BinaryExpr:
+		if factor, ok := functionContext.driverFactor(exp.X); ok {
+			return factor, true
+		}
+		return functionContext.driverFactor(exp.Y)
+	case *ast.IndexExpr:
+		if factor, ok := functionContext.driverFactor(exp.X); ok {
+			return factor, true
+		}
+		return functionContext.driverFactor(exp.Index)
+	case *ast.SliceExpr:
+		return functionContext.driverFactor(exp.X)
+	case *ast.ParenExpr:
+		return functionContext.driverFactor(exp.X)
+	case *ast.UnaryExpr:
+		return functionContext.driverFactor(exp.X)
+	}
+	return Factor{}, false
+}
+
+// rangeFactor names what a range loop iterates over, len(s.items) when it is not a parameter
+func (functionContext *FunctionContext) rangeFactor(expr ast.Expr) Factor {
+	if factor, ok := functionContext.driverFactor(expr); ok {
+		return factor
+	}
+	return Factor{Input: "len(" + nodeString(expr) + ")", Index: 1}
+}
+
+// callFactor names the input a call's cost grows with after the first argument depending on
+// a parameter, or n when none does
+func (functionContext *FunctionContext) callFactor(call *ast.CallExpr, index float32) Factor {
+	for _, arg := range call.Args {
+		if factor, ok := functionContext.driverFactor(arg); ok {
+			factor.Index = index
+			return factor
+		}
+	}
+	return Factor{Input: "n", Index: index}
+}
+
+// countCall records how many times a call runs, as often as the loops around it
+func (functionContext *FunctionContext) countCall(call *ast.CallExpr) {
+	if site, ok := functionContext.callSites[call]; ok {
+		functionContext.Calls[site].Times = functionContext.CurrentDepth
+		functionContext.Calls[site].Inputs = normaliseTerm(functionContext.Inputs)
+	}
+}
+
+// enterLoop enters a loop driven by the factor, exitLoop leaves it again
+func (functionContext *FunctionContext) enterLoop(stmt ast.Stmt, factor Factor) int {
+	functionContext.loops = append(functionContext.loops, stmt)
+	functionContext.Inputs = append(functionContext.Inputs, factor)
+	functionContext.CurrentDepth += factor.Index
+	functionContext.addEvidence(stmt, EvidenceLoop, functionContext.CurrentDepth, 0, "loop over %s, %s", factor.Input, FormatComplexity(functionContext.CurrentDepth))
+	if factor.Param != "" {
+		functionContext.paramLoops++
+	}
+	return len(functionContext.Inputs) - 1
+}
+
+func (functionContext *FunctionContext) exitLoop(loop int) {
+	if functionContext.Inputs[loop].Param != "" {
+		functionContext.paramLoops--
+	}
+	functionContext.CurrentDepth -= functionContext.Inputs[loop].Index
+	functionContext.loops = functionContext.loops[:len(functionContext.loops)-1]
+	functionContext.Inputs = slices.Delete(functionContext.Inputs, loop, loop+1)
+}
+
+// recordTime keeps the most expensive depth reached so far along with the inputs driving it,
+// extra factors are the cost of a call made at this depth
+func (functionContext *FunctionContext) recordTime(depth float32, extra ...Factor) {
+	functionContext.own.Time = max(functionContext.own.Time, depth)
+	functionContext.recordTerm(depth, extra...)
+}
+
+// recordTerm is recordTime for the cost of calls to other analysed functions, which is not
+// the function's own
+func (functionContext *FunctionContext) recordTerm(depth float32, extra ...Factor) {
+	if depth < functionContext.MaxDepth {
+		return
+	}
+	if depth > functionContext.MaxDepth {
+		functionContext.MaxDepth = depth
+		functionContext.Terms = nil
+	}
+	term := normaliseTerm(append(slices.Clone(functionContext.Inputs), extra...))
+	if len(term) == 0 {
+		return
+	}
+	for _, existing := range functionContext.Terms {
+		if sameTerm(existing, term) {
+			return
+		}
+	}
+	functionContext.Terms = append(functionContext.Terms, term)
+}
+
+// normaliseTerm merges the factors of the same input, outermost first, e.g. n · n is n^2
+func normaliseTerm(factors []Factor) Term {
+	var term Term
+	for _, factor := range factors {
+		if factor.Index == 0 {
+			continue
+		}
+		merged := false
+		for i := range term {
+			if term[i].Input == factor.Input {
+				term[i].Index += factor.Index
+				merged = true
+			}
+		}
+		if !merged {
+			term = append(term, factor)
+		}
+	}
+	return term
+}
+
+func sameTerm(a, b Term) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, factor := range a {
+		if !slices.Contains(b, factor) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+package analyser
+
+import "fmt"
+
+const RuleThreshold = "threshold"
+
+// Thresholds are the highest complexities a function may have, nil means unlimited
+type Thresholds struct {
+	Time  *float32
+	Space *float32
+}
+
+// Check reports the thresholds a function breaks, unless it ignores the threshold rule
+func (thresholds Thresholds) Check(functionInfo FunctionInfo) []Diagnostic {
+	var diagnostics []Diagnostic
+	if functionInfo.Directives.Ignores(RuleThreshold) {
+		return diagnostics
+	}
+	check := func(kind string, limit *float32, got float32) {
+		if limit != nil && got > *limit {
+			diagnostics = append(diagnostics, Diagnostic{
+				File:     functionInfo.File,
+				Line:     functionInfo.Line,
+				Function: functionInfo.Name,
+				Rule:     RuleThreshold,
+				Message:  fmt.Sprintf("%s complexity %s exceeds the threshold of %s", kind, FormatComplexity(got), FormatComplexity(*limit)),
+			})
+		}
+	}
+	check("time", thresholds.Time, functionInfo.Complexity.Time)
+	check("space", thresholds.Space, functionInfo.Complexity.Space)
+	return diagnostics
+}
@@ -1,36 +1,132 @@
 package analyser
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"go/ast"
 	"go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
 	"strings"
 )
 
 type FileContext struct {
-	Globals []string
+	Package  string
+	FilePath string
+	FileSet  *token.FileSet
+	Globals  []string
+	// Types of the package's expressions, nil when the package was not type checked
+	Types *types.Info
+	// imports are the import declarations, for fixes removing an import they leave unused
+	imports []*ast.GenDecl
 }
 
 type FunctionContext struct {
 	Name            string
+	Package         string
+	Receiver        string
+	File            string
+	Line            int
+	EndLine         int
+	Fingerprint     string
+	Calls           []Call
+	Directives      Directives
+	Diagnostics     []Diagnostic
 	SymbolTable     SymbolTable
 	CurrentDepth    float32
 	MaxDepth        float32
 	CurrentMalloc   float32
 	MaxMalloc       float32
 	RecursiveFanOut int
+	FileSet         *token.FileSet
+	Types           *types.Info
+	// Loops are the loops around the node being visited by registered analysers, outermost first
+	Loops   []ast.Stmt
+	Metrics map[string]float64
+	// Unresolved are the constructs the complexity analysis could not classify
+	Unresolved []Unresolved
+	// Evidence are the constructs counted towards the complexity, in the order they were visited
+	Evidence   []Evidence
+	ParamTypes map[string]ast.Expr
+	// Inputs drive the loops and recursions around the visited node, Terms are the ones
+	// that reached MaxDepth
+	Inputs []Factor
+	Terms  []Term
+	// loops are the loops around the visited node that grow with an input, paramLoops counts
+	// those that a parameter bounds
+	loops      []ast.Stmt
+	paramLoops int
+	// body of the function and the labels of its statements, to place suggested fixes
+	body   *ast.BlockStmt
+	labels map[ast.Stmt]ast.Stmt
+	// scope of the function, nil when it was not type checked, and the imports of its file,
+	// to name and move code in fixes
+	scope   *types.Scope
+	imports []*ast.GenDecl
+	// own is the complexity reached without the cost of callees, callSites index Calls by call
+	own       Complexity
+	callSites map[*ast.CallExpr]int
 }
 
 type FunctionInfo struct {
 	Name        string
+	Package     string
+	Receiver    string
+	File        string
+	Line        int
+	EndLine     int
+	Fingerprint string
 	Complexity  Complexity
+	// Own is the complexity of the function with calls to the other analysed functions counted
+	// as O(1), Complexity includes what they cost
+	Own         Complexity
+	Calls       []Call
 	SymbolTable SymbolTable
 	FanOut      int
+	Directives  Directives
+	Diagnostics []Diagnostic
+	// Metrics are contributed by registered analysers, like "allocations"
+	Metrics map[string]float64
+	// Confidence grades how much of the function was understood, the complexity is only a
+	// lower bound of the real one when it is not high because of the Unresolved constructs
+	Confidence Confidence
+	Unresolved []Unresolved
+	// Terms name the inputs driving the time complexity, TimeByInput formats them like
+	// "O(len(users) · limit)"
+	Terms       []Term
+	TimeByInput string
+	// Evidence are the loops, allocations, recursive and expensive calls the complexity is made of
+	Evidence []Evidence
 }
 
 type SymbolTable struct {
 	Locals  []string
 	Params  []string
 	Globals []string
+	// Bounded are the params that an assume directive limits to a constant size
+	Bounded []string
+}
+
+// Call is a call site inside a function, Name is the callee as written: "helper", "strings.Split" or "stack.Push"
+type Call struct {
+	Name string
+	Line int
+	// Times is how many times the call runs per call of the function as a complexity index,
+	// Inputs are the loops driving it. Calls the analysis does not reach count once
+	Times  float32
+	Inputs Term
+}
+
+// Reaches resolves a call of caller by name: plain calls reach functions of the same package,
+// selector calls reach methods with that name or functions of the named package
+func (call Call) Reaches(caller FunctionInfo, callee FunctionInfo) bool {
+	qualifier, name, isSelector := strings.Cut(call.Name, ".")
+	if !isSelector {
+		return qualifier == callee.Name && callee.Receiver == "" && filepath.Dir(caller.File) == filepath.Dir(callee.File)
+	}
+	return name == callee.Name && (callee.Receiver != "" || qualifier == callee.Package)
 }
 
 type Complexity struct {
@@ -38,25 +134,51 @@ type Complexity struct {
 	Space float32
 }
 
-
 func ParseContextToInfo(functionContext *FunctionContext) FunctionInfo {
+	var diagnostics []Diagnostic
+	for _, diagnostic := range append(functionContext.Diagnostics, CheckExpectations(functionContext)...) {
+		if !functionContext.Directives.Ignores(diagnostic.Rule) {
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+
 	return FunctionInfo{
-		Name: functionContext.Name,
-		Complexity: Complexity {
+		Name:        functionContext.Name,
+		Package:     functionContext.Package,
+		Receiver:    functionContext.Receiver,
+		File:        functionContext.File,
+		Line:        functionContext.Line,
+		EndLine:     functionContext.EndLine,
+		Fingerprint: functionContext.Fingerprint,
+		Calls:       functionContext.Calls,
+		Complexity: Complexity{
 			Time:  functionContext.MaxDepth,
 			Space: functionContext.MaxMalloc,
 		},
+		Own:         functionContext.own,
 		SymbolTable: functionContext.SymbolTable,
 		FanOut:      functionContext.RecursiveFanOut,
+		Directives:  functionContext.Directives,
+		Diagnostics: diagnostics,
+		Metrics:     functionContext.Metrics,
+		Confidence:  GetConfidence(functionContext.Unresolved),
+		Unresolved:  functionContext.Unresolved,
+		Terms:       functionContext.Terms,
+		TimeByInput: FormatTerms(functionContext.Terms),
+		Evidence:    functionContext.Evidence,
 	}
 }
 
 func GetFileContext(file *ast.File) FileContext {
 	var fileContext FileContext
+	fileContext.Package = file.Name.Name
 
 	for _, declaration := range file.Decls {
 		switch decl := declaration.(type) {
 		case *ast.GenDecl:
+			if decl.Tok == token.IMPORT {
+				fileContext.imports = append(fileContext.imports, decl)
+			}
 			for _, spec := range decl.Specs {
 				if valSpec, ok := spec.(*ast.ValueSpec); ok {
 					for _, identifier := range valSpec.Names {
@@ -73,15 +195,42 @@ func GetFileContext(file *ast.File) FileContext {
 func GetFunctionContext(decl *ast.FuncDecl, fileContext *FileContext) *FunctionContext {
 	functionContext := &FunctionContext{}
 	functionContext.Name = decl.Name.Name
+	functionContext.Package = fileContext.Package
+	functionContext.Receiver = ReceiverName(decl)
+	functionContext.File = fileContext.FilePath
+	functionContext.FileSet = fileContext.FileSet
+	functionContext.Types = fileContext.Types
+	functionContext.imports = fileContext.imports
+	if fileContext.Types != nil {
+		functionContext.scope = fileContext.Types.Scopes[decl.Type]
+	}
+	if fileContext.FileSet != nil {
+		functionContext.Line = fileContext.FileSet.Position(decl.Pos()).Line
+		functionContext.EndLine = fileContext.FileSet.Position(decl.End()).Line
+	}
+	functionContext.Fingerprint = Fingerprint(decl)
+	functionContext.Calls, functionContext.callSites = getCalls(decl, fileContext.FileSet)
+	functionContext.Directives, functionContext.Diagnostics = ParseDirectives(decl, fileContext)
 
 	functionContext.SymbolTable.Globals = fileContext.Globals
 
 	// add parameters
+	functionContext.ParamTypes = map[string]ast.Expr{}
 	for _, params := range decl.Type.Params.List {
 		for _, param := range params.Names {
 			functionContext.SymbolTable.Params = append(functionContext.SymbolTable.Params, param.Name)
+			functionContext.ParamTypes[param.Name] = params.Type
+			if _, ok := functionContext.Directives.Assume[param.Name]; ok {
+				functionContext.SymbolTable.Bounded = append(functionContext.SymbolTable.Bounded, param.Name)
+			}
 		}
 	}
+	// functions without a body are implemented in assembly or linked from elsewhere
+	if decl.Body == nil {
+		return functionContext
+	}
+	functionContext.body = decl.Body
+	functionContext.labels = map[ast.Stmt]ast.Stmt{}
 	// add short variable declarations (assignments)
 	for _, stmt := range decl.Body.List {
 		if assingStmt, ok := stmt.(*ast.AssignStmt); ok && assingStmt.Tok == token.DEFINE {
@@ -113,6 +262,9 @@ func GetFunctionContext(decl *ast.FuncDecl, fileContext *FileContext) *FunctionC
 func GetRecursiveComplexity(expr *ast.CallExpr) (float32, float32) {
 	var time float32 = 0
 	var space float32 = 0
+	if len(expr.Args) == 0 {
+		return time, space
+	}
 	if exp, ok := expr.Args[0].(*ast.BinaryExpr); ok {
 		switch exp.Op.String() {
 		case "+", "-":
@@ -126,11 +278,126 @@ func GetRecursiveComplexity(expr *ast.CallExpr) (float32, float32) {
 	return time, space
 }
 
+// QualifiedName identifies a function by package, receiver and name, e.g. "analyser.Stack.Push"
+func (functionInfo FunctionInfo) QualifiedName() string {
+	if functionInfo.Receiver == "" {
+		return functionInfo.Package + "." + functionInfo.Name
+	}
+	return functionInfo.Package + "." + functionInfo.Receiver + "." + functionInfo.Name
+}
+
+// ReceiverName returns the receiver type of a method without pointers and type parameters
+func ReceiverName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return ""
+	}
+	expr := decl.Recv.List[0].Type
+	for {
+		switch exp := expr.(type) {
+		case *ast.StarExpr:
+			expr = exp.X
+		case *ast.ParenExpr:
+			expr = exp.X
+		case *ast.IndexExpr:
+			expr = exp.X
+		case *ast.IndexListExpr:
+			expr = exp.X
+		case *ast.Ident:
+			return exp.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// Fingerprint hashes the shape of a function body, ignoring formatting, comments and the function's own name,
+// so that renamed or moved functions can be recognised
+func Fingerprint(decl *ast.FuncDecl) string {
+	if decl.Body == nil {
+		return ""
+	}
+	hash := sha256.New()
+	ast.Inspect(decl.Body, func(node ast.Node) bool {
+		if node == nil {
+			return false
+		}
+		fmt.Fprint(hash, reflect.TypeOf(node).String(), ";")
+		switch n := node.(type) {
+		case *ast.Ident:
+			if n.Name == decl.Name.Name {
+				fmt.Fprint(hash, "$self;")
+			} else {
+				fmt.Fprint(hash, n.Name, ";")
+			}
+		case *ast.BasicLit:
+			fmt.Fprint(hash, n.Value, ";")
+		case *ast.BinaryExpr:
+			fmt.Fprint(hash, n.Op.String(), ";")
+		case *ast.AssignStmt:
+			fmt.Fprint(hash, n.Tok.String(), ";")
+		case *ast.IncDecStmt:
+			fmt.Fprint(hash, n.Tok.String(), ";")
+		case *ast.UnaryExpr:
+			fmt.Fprint(hash, n.Op.String(), ";")
+		}
+		return true
+	})
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// GetCalls lists every call site of a function body, calls that are not made through a name are skipped
+func GetCalls(decl *ast.FuncDecl, fset *token.FileSet) []Call {
+	calls, _ := getCalls(decl, fset)
+	return calls
+}
+
+func getCalls(decl *ast.FuncDecl, fset *token.FileSet) ([]Call, map[*ast.CallExpr]int) {
+	var calls []Call
+	sites := map[*ast.CallExpr]int{}
+	if decl.Body == nil {
+		return calls, sites
+	}
+	ast.Inspect(decl.Body, func(node ast.Node) bool {
+		callExpr, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if name := CalleeName(callExpr); name != "" {
+			call := Call{Name: name}
+			if fset != nil {
+				call.Line = fset.Position(callExpr.Pos()).Line
+			}
+			sites[callExpr] = len(calls)
+			calls = append(calls, call)
+		}
+		return true
+	})
+	return calls, sites
+}
+
+// CalleeName is the callee of a call as written: "helper", "strings.Split" or "stack.Push",
+// calls through anything else than a name are named after the selected method only
+func CalleeName(callExpr *ast.CallExpr) string {
+	switch fun := callExpr.Fun.(type) {
+	case *ast.Ident:
+		return fun.Name
+	case *ast.SelectorExpr:
+		if x, ok := fun.X.(*ast.Ident); ok {
+			return x.Name + "." + fun.Sel.Name
+		}
+		return fun.Sel.Name
+	}
+	return ""
+}
+
 func isFunctionName(funcDecl *ast.FuncDecl, funcName string) bool {
 	return strings.ToLower(funcName) == strings.ToLower(funcDecl.Name.Name)
 }
 
 func IsParam(name string, symbolTable *SymbolTable) bool {
+	if IsBounded(name, symbolTable) {
+		return false
+	}
 	for _, param := range symbolTable.Params {
 		if param == name {
 			return true
@@ -139,6 +406,15 @@ func IsParam(name string, symbolTable *SymbolTable) bool {
 	return false
 }
 
+func IsBounded(name string, symbolTable *SymbolTable) bool {
+	for _, bounded := range symbolTable.Bounded {
+		if bounded == name {
+			return true
+		}
+	}
+	return false
+}
+
 func ExprContainsParam(expr ast.Expr, symbolTable *SymbolTable) bool {
 	switch exp := expr.(type) {
 	case *ast.Ident:
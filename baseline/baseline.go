@@ -0,0 +1,199 @@
+package baseline
+
+import (
+	"encoding/json"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const Version = 1
+
+type Baseline struct {
+	Version   int     `json:"version"`
+	Functions []Entry `json:"functions"`
+}
+
+// Entry is the recorded complexity of a single function. Functions are identified by
+// package, receiver and name; file and line are kept for reporting only
+type Entry struct {
+	Package     string  `json:"package"`
+	Receiver    string  `json:"receiver,omitempty"`
+	Name        string  `json:"name"`
+	File        string  `json:"file"`
+	Line        int     `json:"line"`
+	Fingerprint string  `json:"fingerprint"`
+	Time        float32 `json:"time"`
+	Space       float32 `json:"space"`
+}
+
+// Change pairs a function with its entry in an older baseline, Match is empty and Old the
+// zero Entry when the function is new
+type Change struct {
+	Old   Entry  `json:"old"`
+	New   Entry  `json:"new"`
+	Match string `json:"match"`
+}
+
+type Regression struct {
+	Old   Entry  `json:"old"`
+	New   Entry  `json:"new"`
+	Match string `json:"match"`
+}
+
+const (
+	MatchExact   = "exact"
+	MatchRenamed = "renamed"
+	MatchMoved   = "moved"
+)
+
+// New builds a baseline out of analysed functions. The package of each function is the
+// slash separated directory of its file relative to root, so same-named packages do not collide
+func New(root string, funcsInfo []analyser.FunctionInfo) Baseline {
+	baseline := Baseline{Version: Version}
+	for _, fn := range funcsInfo {
+		baseline.Functions = append(baseline.Functions, NewEntry(root, fn))
+	}
+	sort.SliceStable(baseline.Functions, func(i, j int) bool {
+		return baseline.Functions[i].Key() < baseline.Functions[j].Key()
+	})
+	return baseline
+}
+
+// NewEntry records a function, its package is relative to root like in New
+func NewEntry(root string, fn analyser.FunctionInfo) Entry {
+	file := fn.File
+	if absFile, err := filepath.Abs(fn.File); err == nil {
+		if absRoot, err := filepath.Abs(root); err == nil {
+			if rel, err := filepath.Rel(absRoot, absFile); err == nil {
+				file = rel
+			}
+		}
+	}
+	file = filepath.ToSlash(file)
+	return Entry{
+		Package:     packagePath(file, fn.Package),
+		Receiver:    fn.Receiver,
+		Name:        fn.Name,
+		File:        file,
+		Line:        fn.Line,
+		Fingerprint: fn.Fingerprint,
+		Time:        fn.Complexity.Time,
+		Space:       fn.Complexity.Space,
+	}
+}
+
+func Read(path string) (Baseline, error) {
+	var baseline Baseline
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return baseline, err
+	}
+	err = json.Unmarshal(src, &baseline)
+	return baseline, err
+}
+
+func Write(path string, baseline Baseline) error {
+	jsonBytes, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(jsonBytes, '\n'), 0o644)
+}
+
+func (entry Entry) Key() string {
+	if entry.Receiver == "" {
+		return entry.Package + "." + entry.Name
+	}
+	return entry.Package + "." + entry.Receiver + "." + entry.Name
+}
+
+func (entry Entry) IsWorseThan(old Entry) bool {
+	return entry.Time > old.Time || entry.Space > old.Space
+}
+
+// Diff reports the functions of current whose complexity got worse compared to old
+func Diff(old Baseline, current Baseline) []Regression {
+	var regressions []Regression
+	for _, change := range Match(old, current) {
+		if change.Match != "" && change.New.IsWorseThan(change.Old) {
+			regressions = append(regressions, Regression(change))
+		}
+	}
+	return regressions
+}
+
+// Match pairs every function of current with its entry in old, in the order of current.
+// Functions are first matched by package, receiver and name. Whatever is left is matched
+// heuristically: an identical body fingerprint means the function was renamed or moved,
+// and a unique function with the same receiver and name in another package means it was moved
+func Match(old Baseline, current Baseline) []Change {
+	changes := make([]Change, len(current.Functions))
+	oldByKey := map[string]Entry{}
+	for _, entry := range old.Functions {
+		oldByKey[entry.Key()] = entry
+	}
+
+	var unmatched []int
+	for i, entry := range current.Functions {
+		changes[i].New = entry
+		if previous, ok := oldByKey[entry.Key()]; ok {
+			delete(oldByKey, entry.Key())
+			changes[i].Old, changes[i].Match = previous, MatchExact
+		} else {
+			unmatched = append(unmatched, i)
+		}
+	}
+
+	var stillUnmatched []int
+	for _, i := range unmatched {
+		entry := current.Functions[i]
+		previous, ok := uniqueCandidate(oldByKey, func(candidate Entry) bool {
+			return entry.Fingerprint != "" && candidate.Fingerprint == entry.Fingerprint
+		})
+		if !ok {
+			stillUnmatched = append(stillUnmatched, i)
+			continue
+		}
+		delete(oldByKey, previous.Key())
+		changes[i].Old, changes[i].Match = previous, MatchRenamed
+		if previous.Package != entry.Package {
+			changes[i].Match = MatchMoved
+		}
+	}
+
+	for _, i := range stillUnmatched {
+		entry := current.Functions[i]
+		previous, ok := uniqueCandidate(oldByKey, func(candidate Entry) bool {
+			return candidate.Name == entry.Name && candidate.Receiver == entry.Receiver
+		})
+		if !ok {
+			continue
+		}
+		delete(oldByKey, previous.Key())
+		changes[i].Old, changes[i].Match = previous, MatchMoved
+	}
+
+	return changes
+}
+
+func uniqueCandidate(entries map[string]Entry, matches func(Entry) bool) (Entry, bool) {
+	var found Entry
+	count := 0
+	for _, candidate := range entries {
+		if matches(candidate) {
+			found = candidate
+			count++
+		}
+	}
+	return found, count == 1
+}
+
+func packagePath(file string, packageName string) string {
+	dir := filepath.ToSlash(filepath.Dir(file))
+	if dir == "." {
+		return packageName
+	}
+	return dir
+}
@@ -0,0 +1,182 @@
+// Package cache stores analysed functions on disk between runs, keyed by everything their
+// analysis depends on, see analyser.Cache
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+const statsFile = "stats.json"
+
+// Cache is a directory of entries, one file per key, safe for concurrent use by the workers
+// of a run and by concurrent runs. Failing to read or write an entry only costs a re-analysis
+type Cache struct {
+	dir    string
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// Stats describe a cache directory, hits and misses are counted over every run that used it
+type Stats struct {
+	Dir     string `json:"dir"`
+	Entries int    `json:"entries"`
+	Size    int64  `json:"size"`
+	Hits    int64  `json:"hits"`
+	Misses  int64  `json:"misses"`
+}
+
+// DefaultDir is the funalyser directory of the user's cache directory
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "funalyser"), nil
+}
+
+func Open(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Cache{dir: dir}, nil
+}
+
+func (cache *Cache) Get(key string) (analyser.FunctionInfo, bool) {
+	var functionInfo analyser.FunctionInfo
+	src, err := os.ReadFile(cache.entryPath(key))
+	if err == nil {
+		err = json.Unmarshal(src, &functionInfo)
+	}
+	if err != nil {
+		cache.misses.Add(1)
+		return analyser.FunctionInfo{}, false
+	}
+	cache.hits.Add(1)
+	return functionInfo, true
+}
+
+// Put writes an entry to a temporary file first, so readers never see half an entry
+func (cache *Cache) Put(key string, functionInfo analyser.FunctionInfo) {
+	src, err := json.Marshal(functionInfo)
+	if err != nil {
+		return
+	}
+	path := cache.entryPath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = temp.Write(src)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+	}
+}
+
+// Flush adds the hits and misses counted since the last flush to the stats of the directory
+func (cache *Cache) Flush() error {
+	stats, err := readCounts(cache.dir)
+	if err != nil {
+		return err
+	}
+	stats.Hits += cache.hits.Swap(0)
+	stats.Misses += cache.misses.Swap(0)
+	src, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(cache.dir, statsFile), src, 0o644)
+}
+
+// entryPath spreads entries over subdirectories named after the first byte of their key
+func (cache *Cache) entryPath(key string) string {
+	return filepath.Join(cache.dir, key[:2], key+".json")
+}
+
+// ReadStats counts the entries of a cache directory, a missing directory is an empty cache
+func ReadStats(dir string) (Stats, error) {
+	stats, err := readCounts(dir)
+	if err != nil {
+		return stats, err
+	}
+	stats.Dir = dir
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && !isEntryDir(entry.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if path == filepath.Join(dir, entry.Name()) || !strings.HasSuffix(path, ".json") {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		stats.Entries++
+		stats.Size += info.Size()
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return stats, nil
+	}
+	return stats, err
+}
+
+func readCounts(dir string) (Stats, error) {
+	var stats Stats
+	src, err := os.ReadFile(filepath.Join(dir, statsFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return stats, nil
+	}
+	if err != nil {
+		return stats, err
+	}
+	if err := json.Unmarshal(src, &stats); err != nil {
+		// counts are informative only, a corrupt stats file starts over
+		return Stats{}, nil
+	}
+	return stats, nil
+}
+
+// Clear removes every entry and the stats of a cache directory, other files in it are left alone
+func Clear(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Name() == statsFile || entry.IsDir() && isEntryDir(entry.Name()) {
+			if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func isEntryDir(name string) bool {
+	return len(name) == 2 && strings.Trim(name, "0123456789abcdef") == ""
+}
@@ -1,27 +1,69 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
-	"strconv"
+	"github.com/DanyloPiatyhorets/funalyser/baseline"
+	"github.com/DanyloPiatyhorets/funalyser/config"
+	"github.com/DanyloPiatyhorets/funalyser/report"
 	"github.com/spf13/cobra"
 	"encoding/json"
+	"io/fs"
+	"os"
+	"runtime"
+	"slices"
+	"sort"
 )
 
 var fileAnalysis = &cobra.Command{
-	Use:   "analyse [file.go]",
-	Short: "Analyse functions in a source file",
+	Use:   "analyse [file.go | dir | -]",
+	Short: "Analyse functions in a source file or directory",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		functionName, _ := cmd.Flags().GetString("func")
-		jsonFlag, _ := cmd.Flags().GetBool("json")
-		funcsInfo, err := analyser.Analyse(args[0], functionName)
+		since, _ := cmd.Flags().GetString("since")
+		project, err := loadProject(cmd)
+		if err != nil {
+			fmt.Println("❌", err)
+			return
+		}
+		if since != "" {
+			changed, err := project.analyseSince(args[0], since)
+			if err != nil {
+				fmt.Println("❌", err)
+			} else if project.format() == formatJSON {
+				outputJSON(newChanges(changed))
+			} else {
+				printChangedFunctions(changed)
+			}
+			return
+		}
+		if project.streams() {
+			err := streamRows(project, args[0], functionName)
+			printFileDiagnostics(project)
+			if err != nil {
+				fmt.Println("❌", err)
+			}
+			return
+		}
+		funcsInfo, err := project.analysePath(args[0], functionName)
+		printFileDiagnostics(project)
 		if err != nil {
 			fmt.Println("❌", err)
 			return
 		} else {
-			if jsonFlag {
-				outputJSON(funcsInfo)
+			if project.format() == formatJSON {
+				outputJSON(report.NewDocument(funcsInfo, project.fileDiagnostics))
+			} else if project.format() == formatMarkdown {
+				outputMarkdown(cmd, project, funcsInfo)
+			} else if slices.Contains(ciFormats, project.format()) {
+				diagnostics, err := project.checkDiagnostics(funcsInfo)
+				if err != nil {
+					fmt.Println("❌", err)
+					return
+				}
+				outputDiagnosticsXML(project, funcsInfo, diagnostics)
 			} else {
 				for _, fn := range funcsInfo {
 					printFunctionReport(fn)
@@ -42,8 +84,22 @@ var info = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(fileAnalysis)
 	rootCmd.AddCommand(info)
+	fileAnalysis.Flags().String("baseline", defaultBaselineFile, "Baseline the markdown format compares functions with, skipped when the default one does not exist")
 	rootCmd.PersistentFlags().String("func", "", "Name of the function to analyse")
 	rootCmd.PersistentFlags().Bool("json", false, "Output the analysis in json format")
+	rootCmd.PersistentFlags().String("since", "", "Only analyse functions changed since a git revision, and their callers")
+	rootCmd.PersistentFlags().String("config", "", "Path of the config file, by default .funalyser.yaml or .funalyser.json is looked up from the working directory")
+	rootCmd.PersistentFlags().String("format", formatText, "Output format: text, json, markdown, csv, tsv, ndjson, junit or checkstyle")
+	rootCmd.PersistentFlags().StringSlice("include", nil, "Globs of files to analyse in directories")
+	rootCmd.PersistentFlags().StringSlice("exclude", nil, "Globs of files to skip in directories")
+	rootCmd.PersistentFlags().String("tests", config.TestsExclude, "Test file policy: exclude, include or only")
+	rootCmd.PersistentFlags().String("max-time", "", "Highest time complexity allowed by check, e.g. O(n^2)")
+	rootCmd.PersistentFlags().String("max-space", "", "Highest space complexity allowed by check, e.g. O(n)")
+	rootCmd.PersistentFlags().Int("jobs", runtime.GOMAXPROCS(0), "Number of files and functions analysed concurrently")
+	rootCmd.PersistentFlags().StringSlice("analysers", nil, "Registered analysers to run on top of the complexity analysis, e.g. allocations")
+	rootCmd.PersistentFlags().String("cache-dir", "", "Directory of the analysis cache, by default funalyser in the user cache directory")
+	rootCmd.PersistentFlags().Bool("no-cache", false, "Analyse every function again instead of reusing cached results")
+	rootCmd.PersistentFlags().StringSlice("cost-model", nil, "Files giving the complexity of calls to code outside the analysed file")
 }
 
 func printFunctionReport(fn analyser.FunctionInfo) {
@@ -57,8 +113,31 @@ func printFunctionReport(fn analyser.FunctionInfo) {
 	if fn.FanOut > 0 {
 		fmt.Printf("  • Fan-out Factor:    %d %s\n", fn.FanOut, fanOutHint(fn.FanOut))
 	}
-	fmt.Printf("  • Time Complexity:   %s\n", parseComplexityIndexToString(fn.Complexity.Time))
-	fmt.Printf("  • Space Complexity:  %s\n", parseComplexityIndexToString(fn.Complexity.Space))
+	fmt.Printf("  • Time Complexity:   %s\n", analyser.FormatLowerBound(fn.Complexity.Time, fn.Unresolved))
+	fmt.Printf("  • Space Complexity:  %s\n", analyser.FormatLowerBound(fn.Complexity.Space, fn.Unresolved))
+	if len(fn.Terms) > 0 {
+		fmt.Printf("  • Time by Input:     %s\n", fn.TimeByInput)
+	}
+	fmt.Printf("  • Confidence:        %s\n", fn.Confidence)
+
+	if len(fn.Unresolved) > 0 {
+		fmt.Println("❔ Unknown Contributions:")
+		for _, unresolved := range fn.Unresolved {
+			fmt.Printf("  • line %d: %s\n", unresolved.Line, unresolved.Message)
+		}
+	}
+
+	if len(fn.Metrics) > 0 {
+		fmt.Println("📏 Metrics:")
+		var names []string
+		for name := range fn.Metrics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("  • %-18s %g\n", name+":", fn.Metrics[name])
+		}
+	}
 
 	if fn.FanOut > 1 {
 		fmt.Println("📌 Notes:")
@@ -70,20 +149,6 @@ func printFunctionReport(fn analyser.FunctionInfo) {
 	fmt.Println(" ")
 }
 
-func parseComplexityIndexToString(maxLoopDepth float32) string {
-	switch maxLoopDepth {
-	case 0:
-		return "O(1)"
-	case 0.5:
-		return "O(log n)"
-	case 1:
-		return "O(n)"
-	case 1.5:
-		return "O(n*log n)"
-	}
-	return "O(n^" + strconv.Itoa(int(maxLoopDepth)) + ")"
-}
-
 func checkmark(ok bool) string {
 	if ok {
 		return "Yes"
@@ -131,8 +196,48 @@ This tool is designed with developers in mind to help them have a quick analysis
 	`)
 }
 
-func outputJSON(funcsInfo []analyser.FunctionInfo) {
-	jsonBytes, err := json.MarshalIndent(funcsInfo, "", "  ")
+func printFileDiagnostics(project *project) {
+	for _, diagnostic := range project.fileDiagnostics {
+		fmt.Fprintf(os.Stderr, "⚠️  %s:%d: %s [%s]\n", diagnostic.File, diagnostic.Line, diagnostic.Message, diagnostic.Rule)
+	}
+}
+
+// streamRows prints a row per function in source order as soon as it can, instead of once all are analysed
+func streamRows(project *project, path string, functionName string) error {
+	rowWriter, err := report.NewRowWriter(os.Stdout, project.format())
+	if err != nil {
+		return err
+	}
+	var writeError error
+	project.stream = func(fn analyser.FunctionInfo) {
+		if writeError == nil {
+			writeError = rowWriter.Write(fn)
+		}
+	}
+	if _, err := project.analysePath(path, functionName); err != nil {
+		return err
+	}
+	return writeError
+}
+
+// outputMarkdown prints a summary for pull request comments, compared with the baseline when there is one
+func outputMarkdown(cmd *cobra.Command, project *project, funcsInfo []analyser.FunctionInfo) {
+	baselineFile, _ := cmd.Flags().GetString("baseline")
+	var old *baseline.Baseline
+	if recorded, err := baseline.Read(baselineFile); err == nil {
+		old = &recorded
+	} else if cmd.Flags().Changed("baseline") || !errors.Is(err, fs.ErrNotExist) {
+		fmt.Println("❌", err)
+		return
+	}
+	if err := report.WriteMarkdown(os.Stdout, funcsInfo, old, project.config.Dir); err != nil {
+		fmt.Println("❌", err)
+	}
+}
+
+// outputJSON prints one of the documents described by report.Schema
+func outputJSON(document any) {
+	jsonBytes, err := json.MarshalIndent(document, "", "  ")
 	if err != nil {
 		fmt.Println("❌ Error encoding JSON:", err)
 		return
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"github.com/DanyloPiatyhorets/funalyser/baseline"
+	"github.com/DanyloPiatyhorets/funalyser/report"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+const defaultBaselineFile = ".funalyser-baseline.json"
+
+var baselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Snapshot function complexities and report regressions against the snapshot",
+}
+
+var baselineWrite = &cobra.Command{
+	Use:   "write [path...]",
+	Short: "Write the current complexity of every function to a baseline file",
+	Run: func(cmd *cobra.Command, args []string) {
+		outputFile, _ := cmd.Flags().GetString("file")
+		current, err := currentBaseline(cmd, args)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		if err := baseline.Write(outputFile, current); err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Recorded %d functions in %s\n", len(current.Functions), outputFile)
+	},
+}
+
+var baselineDiff = &cobra.Command{
+	Use:   "diff [path...]",
+	Short: "Report functions whose complexity got worse since the baseline",
+	Run: func(cmd *cobra.Command, args []string) {
+		baselineFile, _ := cmd.Flags().GetString("file")
+		project, err := loadProject(cmd)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		old, err := baseline.Read(baselineFile)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		current, err := currentBaseline(cmd, args)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+
+		regressions := baseline.Diff(old, current)
+		if project.format() == formatJSON {
+			outputRegressionsJSON(regressions)
+		} else {
+			printRegressions(regressions)
+		}
+		if len(regressions) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(baselineCmd)
+	baselineCmd.AddCommand(baselineWrite)
+	baselineCmd.AddCommand(baselineDiff)
+	baselineCmd.PersistentFlags().String("file", defaultBaselineFile, "Path of the baseline file")
+}
+
+// currentBaseline analyses paths into a baseline whose packages are relative to the
+// project config, so the whole team records the same names wherever they run it from
+func currentBaseline(cmd *cobra.Command, paths []string) (baseline.Baseline, error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	project, err := loadProject(cmd)
+	if err != nil {
+		return baseline.Baseline{}, err
+	}
+	files, err := project.collectGoFiles(paths)
+	if err != nil {
+		return baseline.Baseline{}, err
+	}
+	funcsInfo, err := project.analyseFiles(files)
+	if err != nil {
+		return baseline.Baseline{}, err
+	}
+	return baseline.New(project.config.Dir, funcsInfo), nil
+}
+
+func printRegressions(regressions []baseline.Regression) {
+	if len(regressions) == 0 {
+		fmt.Println("✅ No complexity regressions")
+		return
+	}
+	fmt.Printf("⚠️  %d complexity regression(s):\n", len(regressions))
+	for _, regression := range regressions {
+		fmt.Println()
+		fmt.Printf("🔍 %s (%s:%d)\n", regression.New.Key(), regression.New.File, regression.New.Line)
+		if regression.Match != baseline.MatchExact {
+			fmt.Printf("  • Matched as %s from %s\n", regression.Match, regression.Old.Key())
+		}
+		fmt.Printf("  • Time Complexity:   %s → %s\n", analyser.FormatComplexity(regression.Old.Time), analyser.FormatComplexity(regression.New.Time))
+		fmt.Printf("  • Space Complexity:  %s → %s\n", analyser.FormatComplexity(regression.Old.Space), analyser.FormatComplexity(regression.New.Space))
+	}
+}
+
+func outputRegressionsJSON(regressions []baseline.Regression) {
+	outputJSON(report.NewRegressions(regressions))
+}
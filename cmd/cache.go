@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/DanyloPiatyhorets/funalyser/cache"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of analysed functions",
+}
+
+var cacheStats = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the size and hit rate of the cache",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		project, err := loadProject(cmd)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		dir, err := cacheDir(cmd)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		stats, err := cache.ReadStats(dir)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		if project.format() == formatJSON {
+			jsonBytes, err := json.MarshalIndent(stats, "", "  ")
+			if err != nil {
+				fmt.Println("❌ Error encoding JSON:", err)
+				return
+			}
+			fmt.Println(string(jsonBytes))
+			return
+		}
+		fmt.Println("🗄️  Cache:", stats.Dir)
+		fmt.Printf("  • Entries:  %d\n", stats.Entries)
+		fmt.Printf("  • Size:     %.1f KiB\n", float64(stats.Size)/1024)
+		fmt.Printf("  • Hits:     %d\n", stats.Hits)
+		fmt.Printf("  • Misses:   %d\n", stats.Misses)
+		if lookups := stats.Hits + stats.Misses; lookups > 0 {
+			fmt.Printf("  • Hit rate: %.0f%%\n", 100*float64(stats.Hits)/float64(lookups))
+		}
+	},
+}
+
+var cacheClear = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove every cached result",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := cacheDir(cmd)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		if err := cache.Clear(dir); err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		fmt.Println("✅ Cleared", dir)
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheStats)
+	cacheCmd.AddCommand(cacheClear)
+	rootCmd.AddCommand(cacheCmd)
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"github.com/DanyloPiatyhorets/funalyser/report"
+	"github.com/spf13/cobra"
+	"os"
+	"slices"
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check [path...]",
+	Short: "Fail when complexities contradict //funalyser:expect directives or exceed thresholds",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+		project, err := loadProject(cmd)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		files, err := project.collectGoFiles(args)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		funcsInfo, err := project.analyseFiles(files)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+
+		diagnostics, err := project.checkDiagnostics(funcsInfo)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+
+		switch project.format() {
+		case formatJSON:
+			outputJSON(report.NewCheck(diagnostics))
+		case report.FormatJUnit, report.FormatCheckstyle:
+			outputDiagnosticsXML(project, funcsInfo, diagnostics)
+		default:
+			printDiagnostics(diagnostics)
+		}
+		if slices.ContainsFunc(diagnostics, failsCheck) {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+}
+
+// checkDiagnostics are the diagnostics check reports: those raised on the files, on the
+// functions and the thresholds they break
+func (p *project) checkDiagnostics(funcsInfo []analyser.FunctionInfo) ([]analyser.Diagnostic, error) {
+	diagnostics := slices.Clone(p.fileDiagnostics)
+	for _, fn := range funcsInfo {
+		diagnostics = append(diagnostics, fn.Diagnostics...)
+	}
+	thresholdDiagnostics, err := p.thresholdDiagnostics(funcsInfo)
+	if err != nil {
+		return nil, err
+	}
+	return append(diagnostics, thresholdDiagnostics...), nil
+}
+
+// failsCheck tells whether check fails on the diagnostic, advisory ones are only warnings
+func failsCheck(diagnostic analyser.Diagnostic) bool {
+	return !diagnostic.Advisory()
+}
+
+// outputDiagnosticsXML prints diagnostics as JUnit test cases or Checkstyle errors
+func outputDiagnosticsXML(project *project, funcsInfo []analyser.FunctionInfo, diagnostics []analyser.Diagnostic) {
+	write := report.WriteJUnit
+	if project.format() == report.FormatCheckstyle {
+		write = report.WriteCheckstyle
+	}
+	if err := write(os.Stdout, funcsInfo, diagnostics); err != nil {
+		fmt.Println("❌", err)
+	}
+}
+
+func printDiagnostics(diagnostics []analyser.Diagnostic) {
+	for _, diagnostic := range diagnostics {
+		mark := "❌"
+		if diagnostic.Advisory() {
+			mark = "⚠️"
+		}
+		if diagnostic.Function == "" {
+			fmt.Printf("%s %s:%d: %s [%s]\n", mark, diagnostic.File, diagnostic.Line, diagnostic.Message, diagnostic.Rule)
+			continue
+		}
+		fmt.Printf("%s %s:%d: %s: %s [%s]\n", mark, diagnostic.File, diagnostic.Line, diagnostic.Function, diagnostic.Message, diagnostic.Rule)
+	}
+	if !slices.ContainsFunc(diagnostics, failsCheck) {
+		fmt.Println("✅ All checks passed")
+	}
+}
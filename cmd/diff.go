@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around changes
+const diffContext = 3
+
+// maxDiffCells bounds the table of the longest common subsequence, beyond it the changed
+// middle of a file is shown as removed and added in one block
+const maxDiffCells = 4_000_000
+
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// unifiedDiff shows the changes between two versions of a file like `diff -u`
+func unifiedDiff(path string, old []byte, new []byte) string {
+	if string(old) == string(new) {
+		return ""
+	}
+	lines := diffLines(splitLines(string(old)), splitLines(string(new)))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", path, path)
+	oldLine, newLine := 1, 1
+	for start := 0; start < len(lines); {
+		first := start
+		for first < len(lines) && lines[first].kind == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		from := max(first-diffContext, start)
+		for i := start; i < from; i++ {
+			oldLine++
+			newLine++
+		}
+		// a hunk goes on while the changes are separated by less than twice the context
+		to := first
+		for i := first; i < len(lines); i++ {
+			if lines[i].kind != ' ' {
+				to = i + 1
+			} else if i-to >= 2*diffContext {
+				break
+			}
+		}
+		to = min(to+diffContext, len(lines))
+
+		oldCount, newCount := 0, 0
+		for _, line := range lines[from:to] {
+			if line.kind != '+' {
+				oldCount++
+			}
+			if line.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount)
+		for _, line := range lines[from:to] {
+			fmt.Fprintf(&out, "%c%s\n", line.kind, line.text)
+		}
+		oldLine += oldCount
+		newLine += newCount
+		start = to
+	}
+	return out.String()
+}
+
+func splitLines(text string) []string {
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines lines up two versions by their longest common subsequence, after the common
+// beginning and end are set aside
+func diffLines(old []string, new []string) []diffLine {
+	prefix := 0
+	for prefix < len(old) && prefix < len(new) && old[prefix] == new[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(new)-prefix && old[len(old)-1-suffix] == new[len(new)-1-suffix] {
+		suffix++
+	}
+
+	var lines []diffLine
+	for _, line := range old[:prefix] {
+		lines = append(lines, diffLine{' ', line})
+	}
+	a, b := old[prefix:len(old)-suffix], new[prefix:len(new)-suffix]
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			lines = append(lines, diffLine{'-', line})
+		}
+		for _, line := range b {
+			lines = append(lines, diffLine{'+', line})
+		}
+	} else {
+		// common[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+		common := make([][]int, len(a)+1)
+		for i := range common {
+			common[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					common[i][j] = common[i+1][j+1] + 1
+				} else {
+					common[i][j] = max(common[i+1][j], common[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(a) || j < len(b) {
+			switch {
+			case i < len(a) && j < len(b) && a[i] == b[j]:
+				lines = append(lines, diffLine{' ', a[i]})
+				i++
+				j++
+			case j == len(b) || i < len(a) && common[i+1][j] >= common[i][j+1]:
+				lines = append(lines, diffLine{'-', a[i]})
+				i++
+			default:
+				lines = append(lines, diffLine{'+', b[j]})
+				j++
+			}
+		}
+	}
+	for _, line := range old[len(old)-suffix:] {
+		lines = append(lines, diffLine{' ', line})
+	}
+	return lines
+}
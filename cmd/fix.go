@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var fixCmd = &cobra.Command{
+	Use:   "fix [path...]",
+	Short: "Apply the fixes suggested for findings, like preallocating slices or hoisting invariant calls out of loops",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+		showDiff, _ := cmd.Flags().GetBool("diff")
+		project, err := loadProject(cmd)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		files, err := project.collectGoFiles(args)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		funcsInfo, err := project.analyseFiles(files)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+
+		var order []string
+		fixes := map[string][]analyser.SuggestedFix{}
+		for _, fn := range funcsInfo {
+			for _, diagnostic := range fn.Diagnostics {
+				if len(diagnostic.Fixes) == 0 {
+					continue
+				}
+				if _, ok := fixes[diagnostic.File]; !ok {
+					order = append(order, diagnostic.File)
+				}
+				fixes[diagnostic.File] = append(fixes[diagnostic.File], diagnostic.Fixes...)
+			}
+		}
+		if len(order) == 0 {
+			fmt.Println("✅ Nothing to fix")
+			return
+		}
+
+		failed := false
+		for _, file := range order {
+			if err := fixFile(file, fixes[file], showDiff); err != nil {
+				fmt.Printf("❌ %s: %v\n", file, err)
+				failed = true
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(fixCmd)
+	fixCmd.Flags().Bool("diff", false, "Print the changes as a unified diff instead of writing them")
+}
+
+// fixFile applies the fixes of a file, or prints what they would change
+func fixFile(file string, fixes []analyser.SuggestedFix, showDiff bool) error {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	fixed, applied, err := analyser.ApplyFixes(src, fixes)
+	if err != nil {
+		return err
+	}
+	if err := analyser.CheckFixes(file, src, fixed); err != nil {
+		return err
+	}
+	if showDiff {
+		fmt.Print(unifiedDiff(file, src, fixed))
+		return nil
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, fixed, info.Mode().Perm()); err != nil {
+		return err
+	}
+	fmt.Printf("🔧 %s: applied %d of %d fixes\n", file, applied, len(fixes))
+	return nil
+}
@@ -0,0 +1,13 @@
+// funalyser-vet runs the complexity analyser as a go vet tool:
+//
+//	go vet -vettool=$(which funalyser-vet) ./...
+package main
+
+import (
+	"github.com/DanyloPiatyhorets/funalyser/analyser/go/goanalysis"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(goanalysis.Analyzer)
+}
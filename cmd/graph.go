@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/DanyloPiatyhorets/funalyser/report"
+	"github.com/spf13/cobra"
+	"os"
+	"slices"
+	"strings"
+)
+
+var graphFormats = []string{report.FormatDOT, report.FormatMermaid, formatJSON}
+
+var graphCmd = &cobra.Command{
+	Use:   "graph [path...]",
+	Short: "Export the call graph with the own and cumulative complexity of every function",
+	Long: `Export the call graph of the analysed functions as Graphviz DOT, a Mermaid flowchart or JSON.
+Nodes show the complexity of each function on its own and with the functions it calls, edges
+how many times a call runs, so that an expensive function called in a loop stands out:
+
+  funalyser graph . | dot -Tsvg > graph.svg`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+		format, _ := cmd.Flags().GetString("format")
+		if !slices.Contains(graphFormats, format) {
+			fmt.Println("❌", fmt.Errorf("unknown graph format %q, use %s or %s", format, strings.Join(graphFormats[:len(graphFormats)-1], ", "), graphFormats[len(graphFormats)-1]))
+			os.Exit(1)
+		}
+		project, err := loadProject(cmd)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		files, err := project.collectGoFiles(args)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		funcsInfo, err := project.analyseFiles(files)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		graph := report.NewGraph(funcsInfo)
+		switch format {
+		case report.FormatDOT:
+			err = report.WriteDOT(os.Stdout, graph)
+		case report.FormatMermaid:
+			err = report.WriteMermaid(os.Stdout, graph)
+		default:
+			err = report.WriteGraphJSON(os.Stdout, graph)
+		}
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(graphCmd)
+	graphCmd.Flags().String("format", report.FormatDOT, "Graph format: dot, mermaid or json")
+}
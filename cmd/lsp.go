@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/DanyloPiatyhorets/funalyser/lsp"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Run a language server over stdio that shows the complexity above each function",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := lsp.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+			fmt.Fprintln(os.Stderr, "❌", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lspCmd)
+}
@@ -0,0 +1,424 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"github.com/DanyloPiatyhorets/funalyser/cache"
+	"github.com/DanyloPiatyhorets/funalyser/config"
+	"github.com/DanyloPiatyhorets/funalyser/report"
+	"github.com/spf13/cobra"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	formatText     = "text"
+	formatJSON     = "json"
+	formatMarkdown = "markdown"
+)
+
+// rowFormats stream one row or line per function as it is analysed
+var rowFormats = []string{report.FormatCSV, report.FormatTSV, report.FormatNDJSON}
+
+// ciFormats report the diagnostics check fails on, in formats CI systems display natively
+var ciFormats = []string{report.FormatJUnit, report.FormatCheckstyle}
+
+var formats = slices.Concat([]string{formatText, formatJSON, formatMarkdown}, rowFormats, ciFormats)
+
+const (
+	// stdinPath is the argument that makes analyse read the source from stdin
+	stdinPath  = "-"
+	stdinLabel = "<stdin>"
+)
+
+// project joins the discovered config file with the command line, flags win over the file
+type project struct {
+	config     *config.Config
+	cmd        *cobra.Command
+	costModels map[string]analyser.CostModel
+	cache      *cache.Cache
+	cacheOpen  bool
+	// fileDiagnostics are raised on whole files by the runs, like syntax errors
+	fileDiagnostics []analyser.Diagnostic
+	// stream receives every function as soon as it is analysed, the runs return none then
+	stream func(analyser.FunctionInfo)
+}
+
+func loadProject(cmd *cobra.Command) (*project, error) {
+	configPath, _ := cmd.Flags().GetString("config")
+	var projectConfig *config.Config
+	var err error
+	if configPath != "" {
+		projectConfig, err = config.Load(configPath)
+	} else {
+		projectConfig, err = config.Discover(".")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tests, _ := cmd.Flags().GetString("tests")
+	if err := config.ValidateTests(tests); err != nil {
+		return nil, err
+	}
+	format := projectConfig.Format
+	if cmd.Flags().Changed("format") || format == "" {
+		format, _ = cmd.Flags().GetString("format")
+	}
+	if jsonFlag, _ := cmd.Flags().GetBool("json"); jsonFlag {
+		format = formatJSON
+	}
+	if cmd.Flags().Lookup("format") != rootCmd.PersistentFlags().Lookup("format") {
+		// commands with a --format of their own, like graph, check it themselves
+		format = formatText
+	}
+	if !slices.Contains(formats, format) {
+		return nil, fmt.Errorf("unknown format %q, use %s or %s", format, strings.Join(formats[:len(formats)-1], ", "), formats[len(formats)-1])
+	}
+	projectConfig.Format = format
+	return &project{config: projectConfig, cmd: cmd, costModels: map[string]analyser.CostModel{}}, nil
+}
+
+func (p *project) format() string {
+	return p.config.Format
+}
+
+// streams tells whether the output format is written one function at a time
+func (p *project) streams() bool {
+	return slices.Contains(rowFormats, p.format())
+}
+
+// settingsFor resolves the config of a file and applies the flags set on the command line
+func (p *project) settingsFor(file string) (config.Settings, error) {
+	settings := p.config.For(file)
+	flags := p.cmd.Flags()
+	if flags.Changed("include") {
+		settings.Include, _ = flags.GetStringSlice("include")
+	}
+	if flags.Changed("exclude") {
+		settings.Exclude, _ = flags.GetStringSlice("exclude")
+	}
+	if flags.Changed("tests") {
+		settings.Tests, _ = flags.GetString("tests")
+	}
+	if flags.Changed("cost-model") {
+		settings.CostModels, _ = flags.GetStringSlice("cost-model")
+	}
+	for flag, threshold := range map[string]**float32{"max-time": &settings.Thresholds.Time, "max-space": &settings.Thresholds.Space} {
+		if flags.Changed(flag) {
+			value, _ := flags.GetString(flag)
+			complexity, err := analyser.ParseComplexity(value)
+			if err != nil {
+				return settings, fmt.Errorf("--%s: %w", flag, err)
+			}
+			*threshold = &complexity
+		}
+	}
+	return settings, nil
+}
+
+// collectGoFiles expands directories into the Go source files they contain, skipping
+// vendored code and hidden directories. Files named explicitly are always analysed
+func (p *project) collectGoFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(current string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			name := entry.Name()
+			if entry.IsDir() {
+				if current != path && skipsDir(name) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.HasSuffix(name, ".go") {
+				return nil
+			}
+			settings, err := p.settingsFor(current)
+			if err != nil {
+				return err
+			}
+			if settings.Includes(p.config.Rel(current)) {
+				files = append(files, current)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// skipsDir tells whether the files of a directory below the analysed path are left out
+func skipsDir(name string) bool {
+	return strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata"
+}
+
+func (p *project) analyseFile(file string, functionName string) ([]analyser.FunctionInfo, error) {
+	return p.run([]string{file}, functionName)
+}
+
+// run analyses files together, once per set of cost models, so that calls between the files
+// of a package are accounted for. Functions are returned in the order of the files
+func (p *project) run(files []string, functionName string) ([]analyser.FunctionInfo, error) {
+	return p.runGroups(files, functionName, func(files []string, options analyser.Options) (*analyser.Report, error) {
+		report, err := analyser.Run(p.cmd.Context(), files, options)
+		if err != nil {
+			return nil, err
+		}
+		p.addFileDiagnostics(report)
+		return report, nil
+	})
+}
+
+// runGroups splits files by their set of cost models and analyses each group with analyse
+func (p *project) runGroups(files []string, functionName string, analyse func(files []string, options analyser.Options) (*analyser.Report, error)) ([]analyser.FunctionInfo, error) {
+	var groups []string
+	filesByGroup := map[string][]string{}
+	costModelsByGroup := map[string][]analyser.CostModel{}
+	order := map[string]int{}
+	for i, file := range files {
+		order[file] = i
+		settings, err := p.settingsFor(file)
+		if err != nil {
+			return nil, err
+		}
+		group := strings.Join(settings.CostModels, "\x00")
+		if _, ok := filesByGroup[group]; !ok {
+			costModels, err := p.loadCostModels(settings.CostModels)
+			if err != nil {
+				return nil, err
+			}
+			costModelsByGroup[group] = costModels
+			groups = append(groups, group)
+		}
+		filesByGroup[group] = append(filesByGroup[group], file)
+	}
+
+	if analysisCache := p.openCache(); analysisCache != nil {
+		defer analysisCache.Flush()
+	}
+	var funcsInfo []analyser.FunctionInfo
+	for _, group := range groups {
+		report, err := analyse(filesByGroup[group], p.options(costModelsByGroup[group], functionName))
+		if err != nil {
+			return nil, err
+		}
+		funcsInfo = append(funcsInfo, report.Functions...)
+	}
+	if len(groups) > 1 {
+		sort.SliceStable(funcsInfo, func(i, j int) bool { return order[funcsInfo[i].File] < order[funcsInfo[j].File] })
+	}
+	return funcsInfo, nil
+}
+
+func (p *project) options(costModels []analyser.CostModel, functionName string) analyser.Options {
+	options := analyser.Options{CostModels: costModels, Analysers: p.analysers(), OnFunction: p.stream}
+	options.Jobs, _ = p.cmd.Flags().GetInt("jobs")
+	if analysisCache := p.openCache(); analysisCache != nil {
+		options.Cache = analysisCache
+	}
+	if functionName != "" {
+		options.Functions = []string{functionName}
+	}
+	return options
+}
+
+func (p *project) addFileDiagnostics(report *analyser.Report) {
+	for _, diagnostic := range report.Diagnostics {
+		if diagnostic.Function == "" {
+			p.fileDiagnostics = append(p.fileDiagnostics, diagnostic)
+		}
+	}
+}
+
+// analysers are the registered analysers enabled by the flag or else by the config file
+func (p *project) analysers() []string {
+	if p.cmd.Flags().Changed("analysers") {
+		analysers, _ := p.cmd.Flags().GetStringSlice("analysers")
+		return analysers
+	}
+	return p.config.Analysers
+}
+
+// openCache opens the analysis cache once, analyses run without one when it is disabled or
+// cannot be opened
+func (p *project) openCache() *cache.Cache {
+	if p.cacheOpen {
+		return p.cache
+	}
+	p.cacheOpen = true
+	if noCache, _ := p.cmd.Flags().GetBool("no-cache"); noCache {
+		return nil
+	}
+	dir, err := cacheDir(p.cmd)
+	if err != nil {
+		return nil
+	}
+	p.cache, _ = cache.Open(dir)
+	return p.cache
+}
+
+func cacheDir(cmd *cobra.Command) (string, error) {
+	if dir, _ := cmd.Flags().GetString("cache-dir"); dir != "" {
+		return dir, nil
+	}
+	return cache.DefaultDir()
+}
+
+// analyseStdin analyses source piped in, positions are reported against <stdin>
+func (p *project) analyseStdin(functionName string) ([]analyser.FunctionInfo, error) {
+	settings, err := p.settingsFor(stdinLabel)
+	if err != nil {
+		return nil, err
+	}
+	costModels, err := p.loadCostModels(settings.CostModels)
+	if err != nil {
+		return nil, err
+	}
+	src, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	report, err := analyser.RunSource(p.cmd.Context(), stdinLabel, src, p.options(costModels, functionName))
+	if err != nil {
+		return nil, err
+	}
+	p.addFileDiagnostics(report)
+	return report.Functions, nil
+}
+
+// loadCostModels reads cost model files once and reuses them for every file
+func (p *project) loadCostModels(paths []string) ([]analyser.CostModel, error) {
+	var costModels []analyser.CostModel
+	for _, path := range paths {
+		costModel, ok := p.costModels[path]
+		if !ok {
+			var err error
+			if costModel, err = analyser.LoadCostModel(path); err != nil {
+				return nil, err
+			}
+			p.costModels[path] = costModel
+		}
+		costModels = append(costModels, costModel)
+	}
+	return costModels, nil
+}
+
+// analysePath analyses a file, or every file of a directory, keeping only functionName when it is set.
+// Functions named exactly functionName are kept, or else those named so in another case
+func (p *project) analysePath(path string, functionName string) ([]analyser.FunctionInfo, error) {
+	if path == stdinPath {
+		funcsInfo, err := p.analyseStdin(functionName)
+		return matchFunctions(funcsInfo, functionName), err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		if files, err = p.collectGoFiles([]string{path}); err != nil {
+			return nil, err
+		}
+	}
+	if p.stream != nil && functionName != "" {
+		return nil, p.streamFunction(files, functionName)
+	}
+	if !info.IsDir() {
+		funcsInfo, err := p.analyseFile(path, functionName)
+		return matchFunctions(funcsInfo, functionName), err
+	}
+	funcsInfo, err := p.analyseFiles(files)
+	if err != nil || functionName == "" {
+		return funcsInfo, err
+	}
+	matching := matchFunctions(funcsInfo, functionName)
+	if len(matching) == 0 {
+		return nil, errors.New("no such function in this directory")
+	}
+	return matching, nil
+}
+
+// matchFunctions keeps the functions named functionName, or when none is, those named so in
+// another case. All of them are kept when functionName is empty
+func matchFunctions(funcsInfo []analyser.FunctionInfo, functionName string) []analyser.FunctionInfo {
+	if functionName == "" {
+		return funcsInfo
+	}
+	var exact, folded []analyser.FunctionInfo
+	for _, fn := range funcsInfo {
+		if fn.Name == functionName {
+			exact = append(exact, fn)
+		} else if strings.EqualFold(fn.Name, functionName) {
+			folded = append(folded, fn)
+		}
+	}
+	if len(exact) > 0 {
+		return exact
+	}
+	return folded
+}
+
+// streamFunction streams the functions named functionName out of every function of files.
+// Those named so in another case are held back and only streamed when none is named exactly so
+func (p *project) streamFunction(files []string, functionName string) error {
+	stream := p.stream
+	defer func() { p.stream = stream }()
+	found := false
+	var folded []analyser.FunctionInfo
+	p.stream = func(fn analyser.FunctionInfo) {
+		if fn.Name == functionName {
+			found = true
+			stream(fn)
+		} else if strings.EqualFold(fn.Name, functionName) {
+			folded = append(folded, fn)
+		}
+	}
+	if _, err := p.analyseFiles(files); err != nil {
+		return err
+	}
+	if !found {
+		for _, fn := range folded {
+			stream(fn)
+		}
+	}
+	if !found && len(folded) == 0 {
+		return errors.New("no such function")
+	}
+	return nil
+}
+
+func (p *project) analyseFiles(files []string) ([]analyser.FunctionInfo, error) {
+	return p.run(files, "")
+}
+
+// thresholdDiagnostics checks every function against the thresholds of its file
+func (p *project) thresholdDiagnostics(funcsInfo []analyser.FunctionInfo) ([]analyser.Diagnostic, error) {
+	var diagnostics []analyser.Diagnostic
+	for _, fn := range funcsInfo {
+		settings, err := p.settingsFor(fn.File)
+		if err != nil {
+			return nil, err
+		}
+		diagnostics = append(diagnostics, settings.Thresholds.Check(fn)...)
+	}
+	return diagnostics, nil
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/DanyloPiatyhorets/funalyser/report"
+	"github.com/spf13/cobra"
+	"os"
+	"path/filepath"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report [path...]",
+	Short: "Write a browsable report of the analysis, like a static HTML site with a source heatmap",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+		htmlDir, _ := cmd.Flags().GetString("html")
+		if htmlDir == "" {
+			fmt.Println("❌", errors.New("pick where to write the report, e.g. --html out/"))
+			os.Exit(1)
+		}
+		project, err := loadProject(cmd)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		files, err := project.collectGoFiles(args)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		funcsInfo, err := project.analyseFiles(files)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		if err := report.WriteHTML(htmlDir, funcsInfo); err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Report of %d functions written to %s\n", len(funcsInfo), filepath.Join(htmlDir, "index.html"))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.Flags().String("html", "", "Directory to write a static HTML site into")
+}
@@ -1,8 +1,10 @@
 package cmd
 
 import (
+    "context"
     "github.com/spf13/cobra"
     "os"
+    "os/signal"
 )
 
 var rootCmd = &cobra.Command{
@@ -12,7 +14,10 @@ var rootCmd = &cobra.Command{
 }
 
 func Execute() {
-    if err := rootCmd.Execute(); err != nil {
+    // Ctrl+C cancels the analysis instead of killing the process mid-output
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+    defer stop()
+    if err := rootCmd.ExecuteContext(ctx); err != nil {
         os.Exit(1)
     }
 }
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/DanyloPiatyhorets/funalyser/report"
+	"github.com/spf13/cobra"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the json output format",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Print(string(report.Schema))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"github.com/DanyloPiatyhorets/funalyser/git"
+	"github.com/DanyloPiatyhorets/funalyser/report"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+type changedFunction struct {
+	Function analyser.FunctionInfo
+	Reason   string
+	// Before is the function at the revision, nil when it did not exist then
+	Before *analyser.FunctionInfo
+}
+
+// analyseSince analyses the functions under path that overlap the hunks of `git diff rev`,
+// together with their transitive callers, and pairs each with its complexity as of rev
+func (p *project) analyseSince(path string, rev string) ([]changedFunction, error) {
+	changedLines, err := git.ChangedLines(rev, []string{path})
+	if err != nil {
+		return nil, err
+	}
+	files, err := p.collectGoFiles([]string{path})
+	if err != nil {
+		return nil, err
+	}
+	funcsInfo, err := p.analyseFiles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []changedFunction
+	isSelected := map[int]bool{}
+	for i, fn := range funcsInfo {
+		for _, lineRange := range changedLines[filepath.Clean(fn.File)] {
+			if lineRange.Overlaps(fn.Line, fn.EndLine) {
+				isSelected[i] = true
+				selected = append(selected, changedFunction{Function: fn, Reason: "changed"})
+				break
+			}
+		}
+	}
+
+	for next := 0; next < len(selected); next++ {
+		callee := selected[next].Function
+		for i, caller := range funcsInfo {
+			if !isSelected[i] && callsFunction(caller, callee) {
+				isSelected[i] = true
+				selected = append(selected, changedFunction{Function: caller, Reason: "calls " + callee.QualifiedName()})
+			}
+		}
+	}
+
+	oldFuncs, err := p.analyseRevision(path, rev)
+	if err != nil {
+		return nil, err
+	}
+	for i := range selected {
+		fn := selected[i].Function
+		for _, oldFn := range oldFuncs {
+			if filepath.Clean(oldFn.File) == filepath.Clean(fn.File) && oldFn.Name == fn.Name && oldFn.Receiver == fn.Receiver {
+				selected[i].Before = &oldFn
+				break
+			}
+		}
+	}
+	return selected, nil
+}
+
+// analyseRevision analyses the Go files under path as of rev the way the working tree is
+// analysed, whole packages at once, so that calls into other files cost the same before and
+// after. Files are named as in the working tree
+func (p *project) analyseRevision(path string, rev string) ([]analyser.FunctionInfo, error) {
+	files, err := p.revisionGoFiles(path, rev)
+	if err != nil {
+		return nil, err
+	}
+	sources := map[string][]byte{}
+	for _, file := range files {
+		if sources[file], err = git.Show(rev, file); err != nil {
+			return nil, err
+		}
+	}
+	return p.runGroups(files, "", func(files []string, options analyser.Options) (*analyser.Report, error) {
+		options.OnFunction = nil
+		revisionSources := make([]analyser.Source, len(files))
+		for i, file := range files {
+			revisionSources[i] = analyser.Source{Path: file, Src: sources[file]}
+		}
+		return analyser.RunSources(p.cmd.Context(), revisionSources, options)
+	})
+}
+
+// revisionGoFiles lists the Go files under path as of rev, with the rules collectGoFiles
+// applies to the working tree
+func (p *project) revisionGoFiles(path string, rev string) ([]string, error) {
+	treeFiles, err := git.Files(rev, path)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		return treeFiles, nil
+	}
+	root, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, treeFile := range treeFiles {
+		absolute, err := filepath.Abs(treeFile)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(root, absolute)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasSuffix(rel, ".go") || slices.ContainsFunc(strings.Split(filepath.Dir(rel), string(filepath.Separator)), func(dir string) bool {
+			return dir != "." && skipsDir(dir)
+		}) {
+			continue
+		}
+		file := filepath.Join(path, rel)
+		settings, err := p.settingsFor(file)
+		if err != nil {
+			return nil, err
+		}
+		if settings.Includes(p.config.Rel(file)) {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+func callsFunction(caller analyser.FunctionInfo, callee analyser.FunctionInfo) bool {
+	return slices.ContainsFunc(caller.Calls, func(call analyser.Call) bool {
+		return call.Reaches(caller, callee)
+	})
+}
+
+func printChangedFunctions(changed []changedFunction) {
+	if len(changed) == 0 {
+		fmt.Println("✅ No changed functions")
+		return
+	}
+	for _, fn := range changed {
+		fmt.Println()
+		fmt.Println("───────────────────────────────────────────")
+		fmt.Printf("🔍 Function: %s (%s:%d)\n", fn.Function.Name, fn.Function.File, fn.Function.Line)
+		fmt.Println("─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─")
+		fmt.Printf("  • Reason:            %s\n", fn.Reason)
+		if fn.Before == nil {
+			fmt.Printf("  • Time Complexity:   new → %s\n", analyser.FormatComplexity(fn.Function.Complexity.Time))
+			fmt.Printf("  • Space Complexity:  new → %s\n", analyser.FormatComplexity(fn.Function.Complexity.Space))
+		} else {
+			fmt.Printf("  • Time Complexity:   %s → %s\n", analyser.FormatComplexity(fn.Before.Complexity.Time), analyser.FormatComplexity(fn.Function.Complexity.Time))
+			fmt.Printf("  • Space Complexity:  %s → %s\n", analyser.FormatComplexity(fn.Before.Complexity.Space), analyser.FormatComplexity(fn.Function.Complexity.Space))
+		}
+		fmt.Println("───────────────────────────────────────────")
+	}
+}
+
+func newChanges(changed []changedFunction) report.Changes {
+	changes := report.Changes{SchemaVersion: report.SchemaVersion, Changes: []report.Change{}}
+	for _, fn := range changed {
+		changes.Changes = append(changes.Changes, report.NewChange(fn.Function, fn.Reason, fn.Before))
+	}
+	return changes
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"github.com/DanyloPiatyhorets/funalyser/report"
+	"github.com/DanyloPiatyhorets/funalyser/verify"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify [file.go] --func F",
+	Short: "Benchmark a function with growing inputs and compare the measured complexity with the static one",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		functionName, _ := cmd.Flags().GetString("func")
+		if functionName == "" {
+			fmt.Println("❌ verify needs --func")
+			os.Exit(1)
+		}
+		project, err := loadProject(cmd)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		options := verify.DefaultOptions()
+		if cmd.Flags().Changed("sizes") {
+			options.Sizes, _ = cmd.Flags().GetIntSlice("sizes")
+		}
+		options.BenchTime, _ = cmd.Flags().GetDuration("benchtime")
+		options.Count, _ = cmd.Flags().GetInt("count")
+		options.Timeout, _ = cmd.Flags().GetDuration("timeout")
+
+		fmt.Fprintln(os.Stderr, "⏱️  Benchmarking", functionName, "with sizes", options.Sizes)
+		result, err := verify.Verify(args[0], functionName, options)
+		if err != nil {
+			fmt.Println("❌", err)
+			os.Exit(1)
+		}
+		if project.format() == formatJSON {
+			outputJSON(report.NewVerification(result))
+		} else {
+			printVerifyResult(result)
+		}
+		if !result.TimeAgrees || !result.SpaceAgrees {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+	defaults := verify.DefaultOptions()
+	verifyCmd.Flags().IntSlice("sizes", defaults.Sizes, "Input sizes to benchmark, in increasing order")
+	verifyCmd.Flags().Duration("benchtime", defaults.BenchTime, "Time spent benchmarking each size")
+	verifyCmd.Flags().Int("count", defaults.Count, "Runs of each size, the fastest one is kept")
+	verifyCmd.Flags().Duration("timeout", defaults.Timeout, "Give up when the benchmarks run longer than this")
+}
+
+func printVerifyResult(result *verify.Result) {
+	fmt.Println()
+	fmt.Println("───────────────────────────────────────────")
+	fmt.Printf("🔬 Function: %s\n", result.Function)
+	fmt.Println("─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─ ─")
+	fmt.Println("⏱️  Measurements:")
+	for _, sample := range result.Samples {
+		fmt.Printf("  • n=%-8d %12.0f ns/op %10.0f B/op %8.0f allocs/op\n", sample.N, sample.NsPerOp, sample.BytesPerOp, sample.AllocsPerOp)
+	}
+	fmt.Println("📊 Static vs Measured:")
+	fmt.Printf("  • Time Complexity:   %s vs %s %s\n", analyser.FormatComplexity(result.Static.Time), analyser.FormatComplexity(result.Measured.Time), agreement(result.TimeAgrees))
+	fmt.Printf("  • Space Complexity:  %s vs %s %s\n", analyser.FormatComplexity(result.Static.Space), analyser.FormatComplexity(result.Measured.Space), agreement(result.SpaceAgrees))
+	fmt.Println("───────────────────────────────────────────")
+}
+
+func agreement(agrees bool) string {
+	if agrees {
+		return "✅ agrees"
+	}
+	return "⚠️  disagrees"
+}
@@ -0,0 +1,316 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// FileNames are the project configuration files looked up from the working directory upwards
+var FileNames = []string{".funalyser.yaml", ".funalyser.yml", ".funalyser.json"}
+
+const (
+	TestsExclude = "exclude"
+	TestsInclude = "include"
+	TestsOnly    = "only"
+)
+
+// Config is the project configuration, shared by the whole team through a .funalyser.yaml:
+//
+//	include: ["**/*.go"]
+//	exclude: ["**/*_gen.go"]
+//	tests: exclude
+//	format: text
+//	thresholds: {time: O(n^2), space: O(n)}
+//	costModels: [costs.yaml]
+//	analysers: [allocations]
+//	overrides:
+//	  - path: internal/legacy
+//	    thresholds: {time: O(n^3)}
+type Config struct {
+	Include    []string   `json:"include" yaml:"include"`
+	Exclude    []string   `json:"exclude" yaml:"exclude"`
+	Tests      string     `json:"tests" yaml:"tests"`
+	Format     string     `json:"format" yaml:"format"`
+	Thresholds Thresholds `json:"thresholds" yaml:"thresholds"`
+	CostModels []string   `json:"costModels" yaml:"costModels"`
+	// Analysers are the registered analysers enabled on top of the complexity analysis
+	Analysers []string   `json:"analysers" yaml:"analysers"`
+	Overrides []Override `json:"overrides" yaml:"overrides"`
+
+	// Dir is where the config file lives, paths and globs are relative to it
+	Dir string `json:"-" yaml:"-"`
+	// Path is the config file that was loaded, empty when none was found
+	Path string `json:"-" yaml:"-"`
+}
+
+type Thresholds struct {
+	Time  string `json:"time" yaml:"time"`
+	Space string `json:"space" yaml:"space"`
+}
+
+// Override replaces the settings of the files under Path, later overrides win
+type Override struct {
+	Path       string     `json:"path" yaml:"path"`
+	Include    []string   `json:"include" yaml:"include"`
+	Exclude    []string   `json:"exclude" yaml:"exclude"`
+	Tests      string     `json:"tests" yaml:"tests"`
+	Thresholds Thresholds `json:"thresholds" yaml:"thresholds"`
+	CostModels []string   `json:"costModels" yaml:"costModels"`
+}
+
+// Settings are the options that apply to a single file once overrides are resolved
+type Settings struct {
+	Include    []string
+	Exclude    []string
+	Tests      string
+	Thresholds analyser.Thresholds
+	CostModels []string
+}
+
+// Find walks up from dir and returns the first config file, or "" when there is none
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, name := range FileNames {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Discover loads the config file found from dir upwards, or an empty config rooted at dir
+func Discover(dir string) (*Config, error) {
+	path, err := Find(dir)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+		return &Config{Dir: absDir}, nil
+	}
+	return Load(path)
+}
+
+func Load(path string) (*Config, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	if filepath.Ext(path) == ".json" {
+		err = json.Unmarshal(src, config)
+	} else {
+		err = yaml.Unmarshal(src, config)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if config.Path, err = filepath.Abs(path); err != nil {
+		return nil, err
+	}
+	config.Dir = filepath.Dir(config.Path)
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+func (config *Config) validate() error {
+	thresholds := []Thresholds{config.Thresholds}
+	tests := []string{config.Tests}
+	for _, override := range config.Overrides {
+		if override.Path == "" {
+			return fmt.Errorf("override without a path")
+		}
+		thresholds = append(thresholds, override.Thresholds)
+		tests = append(tests, override.Tests)
+	}
+	for _, threshold := range thresholds {
+		if _, err := threshold.parse(); err != nil {
+			return err
+		}
+	}
+	for _, policy := range tests {
+		if err := ValidateTests(policy); err != nil {
+			return err
+		}
+	}
+	registered := analyser.Analysers()
+	for _, name := range config.Analysers {
+		if !slices.Contains(registered, name) {
+			return fmt.Errorf("unknown analyser %q, registered analysers are %s", name, strings.Join(registered, ", "))
+		}
+	}
+	return nil
+}
+
+func ValidateTests(policy string) error {
+	switch policy {
+	case "", TestsExclude, TestsInclude, TestsOnly:
+		return nil
+	}
+	return fmt.Errorf("unknown test file policy %q, use %s, %s or %s", policy, TestsExclude, TestsInclude, TestsOnly)
+}
+
+// For resolves the settings of a file, applying every override whose path contains it
+func (config *Config) For(file string) Settings {
+	rel := config.Rel(file)
+	settings := Settings{
+		Include:    config.Include,
+		Exclude:    config.Exclude,
+		Tests:      config.Tests,
+		CostModels: config.resolve(config.CostModels),
+	}
+	settings.Thresholds, _ = config.Thresholds.parse()
+
+	for _, override := range config.Overrides {
+		prefix := strings.TrimSuffix(filepath.ToSlash(filepath.Clean(override.Path)), "/")
+		if prefix != "." && rel != prefix && !strings.HasPrefix(rel, prefix+"/") {
+			continue
+		}
+		if override.Include != nil {
+			settings.Include = override.Include
+		}
+		if override.Exclude != nil {
+			settings.Exclude = override.Exclude
+		}
+		if override.Tests != "" {
+			settings.Tests = override.Tests
+		}
+		if override.CostModels != nil {
+			settings.CostModels = config.resolve(override.CostModels)
+		}
+		thresholds, _ := override.Thresholds.parse()
+		if thresholds.Time != nil {
+			settings.Thresholds.Time = thresholds.Time
+		}
+		if thresholds.Space != nil {
+			settings.Thresholds.Space = thresholds.Space
+		}
+	}
+	if settings.Tests == "" {
+		settings.Tests = TestsExclude
+	}
+	return settings
+}
+
+// Rel is the slash separated path of file relative to the config directory
+func (config *Config) Rel(file string) string {
+	absFile, err := filepath.Abs(file)
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+	rel, err := filepath.Rel(config.Dir, absFile)
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Includes tells whether a file found while walking a directory should be analysed
+func (settings Settings) Includes(rel string) bool {
+	isTest := strings.HasSuffix(rel, "_test.go")
+	switch settings.Tests {
+	case TestsExclude:
+		if isTest {
+			return false
+		}
+	case TestsOnly:
+		if !isTest {
+			return false
+		}
+	}
+	if len(settings.Include) > 0 && !matchAny(settings.Include, rel) {
+		return false
+	}
+	return !matchAny(settings.Exclude, rel)
+}
+
+func (config *Config) resolve(paths []string) []string {
+	var resolved []string
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(config.Dir, path)
+		}
+		resolved = append(resolved, path)
+	}
+	return resolved
+}
+
+func (thresholds Thresholds) parse() (analyser.Thresholds, error) {
+	var parsed analyser.Thresholds
+	if thresholds.Time != "" {
+		time, err := analyser.ParseComplexity(thresholds.Time)
+		if err != nil {
+			return parsed, err
+		}
+		parsed.Time = &time
+	}
+	if thresholds.Space != "" {
+		space, err := analyser.ParseComplexity(thresholds.Space)
+		if err != nil {
+			return parsed, err
+		}
+		parsed.Space = &space
+	}
+	return parsed, nil
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if Match(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether a slash separated path matches a glob, where * and ? stay within
+// a path segment and ** spans any number of segments
+func Match(pattern string, path string) bool {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					expr.WriteString("(?:.*/)?")
+				} else {
+					expr.WriteString(".*")
+				}
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+	matched, err := regexp.MatchString(expr.String(), path)
+	return err == nil && matched
+}
@@ -0,0 +1,139 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// LineRange is an inclusive range of lines in the new version of a file
+type LineRange struct {
+	Start int
+	End   int
+}
+
+func (lineRange LineRange) Overlaps(start int, end int) bool {
+	return lineRange.Start <= end && start <= lineRange.End
+}
+
+// ChangedLines runs `git diff` against rev and returns the changed line ranges of every
+// file under paths, keyed by the file path relative to the working directory
+func ChangedLines(rev string, paths []string) (map[string][]LineRange, error) {
+	args := []string{"-c", "core.quotepath=off", "diff", "--no-color", "--no-ext-diff", "--relative", "-U0", rev, "--"}
+	output, err := run(append(args, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	return parseDiff(output)
+}
+
+// Show reads the content of a file, given relative to the working directory, as of rev
+// from the local object store. A file that did not exist at rev yields nil content and no
+// error, a rev that does not name a commit is an error
+func Show(rev string, path string) ([]byte, error) {
+	commit, err := resolve(rev)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(path, "./") && !strings.HasPrefix(path, "../") {
+		path = "./" + path
+	}
+	if _, err := run("cat-file", "-e", commit+":"+path); err != nil {
+		return nil, nil
+	}
+	return run("show", commit+":"+path)
+}
+
+// Files lists the files under path as of rev, path and the files relative to the working
+// directory. A path that did not exist at rev yields no files
+func Files(rev string, path string) ([]string, error) {
+	commit, err := resolve(rev)
+	if err != nil {
+		return nil, err
+	}
+	output, err := run("ls-tree", "-r", "-z", "--name-only", commit, "--", path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, file := range strings.Split(string(output), "\x00") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// resolve turns a revision into the commit it names
+func resolve(rev string) (string, error) {
+	output, err := run("rev-parse", "--verify", "--end-of-options", rev+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func run(args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	command := exec.Command("git", args...)
+	command.Stderr = &stderr
+	output, err := command.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
+
+func parseDiff(output []byte) (map[string][]LineRange, error) {
+	changed := map[string][]LineRange{}
+	var file string
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			file = strings.TrimPrefix(line, "+++ ")
+			if file == "/dev/null" {
+				file = ""
+			} else {
+				file = strings.TrimPrefix(file, "b/")
+			}
+		case strings.HasPrefix(line, "@@ ") && file != "":
+			lineRange, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+			changed[file] = append(changed[file], lineRange)
+		}
+	}
+	return changed, scanner.Err()
+}
+
+// parseHunkHeader reads the new side of "@@ -12,3 +14,0 @@". Pure deletions have
+// no lines on the new side, so they mark the line the removed code used to follow
+func parseHunkHeader(header string) (LineRange, error) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return LineRange{}, fmt.Errorf("malformed hunk header %q", header)
+	}
+	startAndCount := strings.SplitN(strings.TrimPrefix(fields[2], "+"), ",", 2)
+	start, err := strconv.Atoi(startAndCount[0])
+	if err != nil {
+		return LineRange{}, fmt.Errorf("malformed hunk header %q", header)
+	}
+	count := 1
+	if len(startAndCount) == 2 {
+		if count, err = strconv.Atoi(startAndCount[1]); err != nil {
+			return LineRange{}, fmt.Errorf("malformed hunk header %q", header)
+		}
+	}
+	if count == 0 {
+		return LineRange{Start: start, End: start + 1}, nil
+	}
+	return LineRange{Start: start, End: start + count - 1}, nil
+}
@@ -0,0 +1,302 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"github.com/DanyloPiatyhorets/funalyser/config"
+	"io"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// Server answers LSP requests with the complexity of the functions of open documents.
+// Documents are analysed from the in-memory buffers the editor sends, never from disk
+type Server struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	writeLock sync.Mutex
+	documents map[string]*document
+	shutdown  bool
+}
+
+type document struct {
+	path      string
+	text      string
+	funcsInfo []analyser.FunctionInfo
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{in: bufio.NewReader(in), out: out, documents: map[string]*document{}}
+}
+
+// Serve handles messages until the client sends exit or closes the input
+func (server *Server) Serve() error {
+	for {
+		request, err := server.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if request.Method == "exit" {
+			if !server.shutdown {
+				return errors.New("exit before shutdown")
+			}
+			return nil
+		}
+		server.handle(request)
+	}
+}
+
+func (server *Server) handle(request *message) {
+	var result any
+	var err *responseError
+
+	switch request.Method {
+	case "initialize":
+		result = initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync:  textDocumentSyncFull,
+				CodeLensProvider:  codeLensOptions{},
+				InlayHintProvider: true,
+			},
+			ServerInfo: serverInfo{Name: "funalyser"},
+		}
+
+	case "shutdown":
+		server.shutdown = true
+
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err = decode(request.Params, &params); err == nil {
+			server.update(params.TextDocument.URI, params.TextDocument.Text)
+		}
+
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err = decode(request.Params, &params); err == nil && len(params.ContentChanges) > 0 {
+			server.update(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+		}
+
+	case "textDocument/didClose":
+		var params documentParams
+		if err = decode(request.Params, &params); err == nil {
+			delete(server.documents, params.TextDocument.URI)
+			server.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []diagnostic{}})
+		}
+
+	case "textDocument/codeLens":
+		var params documentParams
+		if err = decode(request.Params, &params); err == nil {
+			result = server.codeLenses(params.TextDocument.URI)
+		}
+
+	case "textDocument/inlayHint":
+		var params documentParams
+		if err = decode(request.Params, &params); err == nil {
+			result = server.inlayHints(params.TextDocument.URI)
+		}
+
+	default:
+		if request.ID != nil {
+			err = &responseError{Code: codeMethodNotFound, Message: "method not supported: " + request.Method}
+		}
+	}
+
+	if request.ID == nil {
+		return
+	}
+	response := &message{JSONRPC: "2.0", ID: request.ID, Result: result, Error: err}
+	if result == nil && err == nil {
+		response.Result = json.RawMessage("null")
+	}
+	server.write(response)
+}
+
+// update re-analyses a document from its new content and publishes its diagnostics.
+// While the user is mid-edit, lenses and hints come from the functions that still parse, at
+// their current lines
+func (server *Server) update(uri string, text string) {
+	doc, ok := server.documents[uri]
+	if !ok {
+		doc = &document{path: uriToPath(uri)}
+		server.documents[uri] = doc
+	}
+	doc.text = text
+
+	var diagnostics []diagnostic
+	settings := settingsFor(doc.path)
+	funcsInfo, err := analyseDocument(doc.path, text, settings)
+	doc.funcsInfo = funcsInfo
+	var syntaxError *analyser.SyntaxError
+	if errors.As(err, &syntaxError) {
+		for _, found := range syntaxError.Diagnostics {
+			diagnostics = append(diagnostics, diagnostic{
+				Range:    lineRange(text, found.Line-1),
+				Severity: severityError,
+				Code:     found.Rule,
+				Source:   "funalyser",
+				Message:  found.Message,
+			})
+		}
+	} else if err != nil {
+		diagnostics = append(diagnostics, diagnostic{
+			Range:    lineRange(text, 0),
+			Severity: severityError,
+			Source:   "funalyser",
+			Message:  err.Error(),
+		})
+	}
+	// the functions that parsed are reported even mid-edit
+	for _, fn := range funcsInfo {
+		for _, found := range append(fn.Diagnostics, settings.Thresholds.Check(fn)...) {
+			diagnostics = append(diagnostics, diagnostic{
+				Range:    lineRange(text, found.Line-1),
+				Severity: severityWarning,
+				Code:     found.Rule,
+				Source:   "funalyser",
+				Message:  found.Message,
+			})
+		}
+	}
+	if diagnostics == nil {
+		diagnostics = []diagnostic{}
+	}
+	server.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+}
+
+func (server *Server) codeLenses(uri string) []codeLens {
+	lenses := []codeLens{}
+	doc, ok := server.documents[uri]
+	if !ok {
+		return lenses
+	}
+	for _, fn := range doc.funcsInfo {
+		lenses = append(lenses, codeLens{
+			Range:   lineRange(doc.text, fn.Line-1),
+			Command: command{Title: Summary(fn)},
+		})
+	}
+	return lenses
+}
+
+func (server *Server) inlayHints(uri string) []inlayHint {
+	hints := []inlayHint{}
+	doc, ok := server.documents[uri]
+	if !ok {
+		return hints
+	}
+	for _, fn := range doc.funcsInfo {
+		hints = append(hints, inlayHint{
+			Position:    lineRange(doc.text, fn.Line-1).End,
+			Label:       Summary(fn),
+			Kind:        inlayHintKindType,
+			PaddingLeft: true,
+		})
+	}
+	return hints
+}
+
+// Summary is the one line description shown above a function, e.g. "O(n^2) time · O(n) space",
+// lower bounds are prefixed with ≥ as in "≥ O(n) time · ≥ O(1) space"
+func Summary(fn analyser.FunctionInfo) string {
+	bound := ""
+	if len(fn.Unresolved) > 0 {
+		bound = "≥ "
+	}
+	return fmt.Sprintf("%s%s time · %s%s space", bound, analyser.FormatComplexity(fn.Complexity.Time), bound, analyser.FormatComplexity(fn.Complexity.Space))
+}
+
+// settingsFor reads the project config around a document, the editor may open files of any project
+func settingsFor(path string) config.Settings {
+	projectConfig, err := config.Discover(filepath.Dir(path))
+	if err != nil {
+		return config.Settings{}
+	}
+	return projectConfig.For(path)
+}
+
+func analyseDocument(path string, text string, settings config.Settings) ([]analyser.FunctionInfo, error) {
+	var costModels []analyser.CostModel
+	for _, costModelPath := range settings.CostModels {
+		if costModel, err := analyser.LoadCostModel(costModelPath); err == nil {
+			costModels = append(costModels, costModel)
+		}
+	}
+	return analyser.AnalyseSource(path, []byte(text), "", costModels...)
+}
+
+func (server *Server) read() (*message, error) {
+	headers, err := textproto.NewReader(server.in).ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	length, err := strconv.Atoi(headers.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length %q", headers.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(server.in, body); err != nil {
+		return nil, err
+	}
+	request := &message{}
+	if err := json.Unmarshal(body, request); err != nil {
+		server.write(&message{JSONRPC: "2.0", Error: &responseError{Code: codeParseError, Message: err.Error()}})
+		return &message{}, nil
+	}
+	return request, nil
+}
+
+func (server *Server) notify(method string, params any) {
+	body, _ := json.Marshal(params)
+	server.write(&message{JSONRPC: "2.0", Method: method, Params: body})
+}
+
+func (server *Server) write(response *message) {
+	response.JSONRPC = "2.0"
+	body, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	server.writeLock.Lock()
+	defer server.writeLock.Unlock()
+	fmt.Fprintf(server.out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func decode(params json.RawMessage, target any) *responseError {
+	if err := json.Unmarshal(params, target); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func uriToPath(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(parsed.Path)
+}
+
+// lineRange spans a whole line, with the character offset in UTF-16 code units as LSP wants
+func lineRange(text string, line int) lspRange {
+	lines := strings.Split(text, "\n")
+	if line < 0 || line >= len(lines) {
+		return lspRange{Start: position{Line: max(line, 0)}, End: position{Line: max(line, 0)}}
+	}
+	length := len(utf16.Encode([]rune(strings.TrimRight(lines[line], "\r"))))
+	return lspRange{Start: position{Line: line}, End: position{Line: line, Character: length}}
+}
@@ -0,0 +1,224 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	FormatDOT     = "dot"
+	FormatMermaid = "mermaid"
+)
+
+// Graph is the call graph of the analysed functions. Every node carries the complexity of the
+// function itself and the cumulative one, with what its callees cost, and every edge how many
+// times the caller makes the call, so that an expensive leaf multiplied by a loop stands out.
+// It is also the JSON output of graph
+type Graph struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Nodes         []Node `json:"nodes"`
+	Edges         []Edge `json:"edges"`
+}
+
+type Node struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Receiver   string          `json:"receiver,omitempty"`
+	Package    string          `json:"package"`
+	Position   Range           `json:"position"`
+	Own        GraphComplexity `json:"own"`
+	Cumulative GraphComplexity `json:"cumulative"`
+	cumulative analyser.Complexity
+}
+
+type GraphComplexity struct {
+	Time  string `json:"time"`
+	Space string `json:"space"`
+}
+
+// Edge is every call of a function to another, Times is how many times the most repeated
+// of them runs per call of the caller, like O(len(items))
+type Edge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Lines []int  `json:"lines"`
+	Times string `json:"times"`
+	times float32
+}
+
+// NewGraph links the analysed functions by the calls they make. Calls are resolved by name like
+// --since does, the ones matching several functions, like a method name several types have,
+// are left out
+func NewGraph(funcs []analyser.FunctionInfo) Graph {
+	graph := Graph{SchemaVersion: SchemaVersion, Nodes: []Node{}, Edges: []Edge{}}
+	ids := map[string]int{}
+	for _, fn := range funcs {
+		id := filepath.ToSlash(filepath.Dir(fn.File)) + "." + functionName(fn)
+		if ids[id]++; ids[id] > 1 {
+			id = fmt.Sprintf("%s#%d", id, fn.Line)
+		}
+		graph.Nodes = append(graph.Nodes, Node{
+			ID:       id,
+			Name:     fn.Name,
+			Receiver: fn.Receiver,
+			Package:  fn.Package,
+			Position: Range{File: fn.File, Line: fn.Line, EndLine: fn.EndLine},
+			Own:      graphComplexity(fn.Own),
+		})
+	}
+
+	edges := make([][]int, len(funcs))
+	for caller, fn := range funcs {
+		edgeTo := map[int]int{}
+		for _, call := range fn.Calls {
+			callee := -1
+			for candidate, other := range funcs {
+				if call.Reaches(fn, other) {
+					if callee >= 0 {
+						callee = -1
+						break
+					}
+					callee = candidate
+				}
+			}
+			if callee < 0 {
+				continue
+			}
+			if _, ok := edgeTo[callee]; !ok {
+				edgeTo[callee] = len(graph.Edges)
+				edges[caller] = append(edges[caller], len(graph.Edges))
+				graph.Edges = append(graph.Edges, Edge{From: graph.Nodes[caller].ID, To: graph.Nodes[callee].ID, Lines: []int{}})
+			}
+			edge := &graph.Edges[edgeTo[callee]]
+			edge.Lines = append(edge.Lines, call.Line)
+			if len(edge.Lines) == 1 || call.Times > edge.times {
+				edge.times = call.Times
+				edge.Times = callTimes(call)
+			}
+		}
+	}
+
+	// cumulative complexities follow the calls, calls back into a function being computed are cut
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(funcs))
+	index := map[string]int{}
+	for i, node := range graph.Nodes {
+		index[node.ID] = i
+	}
+	var cumulative func(i int) analyser.Complexity
+	cumulative = func(i int) analyser.Complexity {
+		if state[i] != unvisited {
+			return graph.Nodes[i].cumulative
+		}
+		state[i] = visiting
+		total := analyser.Complexity{Time: max(funcs[i].Own.Time, funcs[i].Complexity.Time), Space: max(funcs[i].Own.Space, funcs[i].Complexity.Space)}
+		graph.Nodes[i].cumulative = total
+		for _, e := range edges[i] {
+			callee := cumulative(index[graph.Edges[e].To])
+			total.Time = max(total.Time, graph.Edges[e].times+callee.Time)
+			total.Space = max(total.Space, callee.Space)
+		}
+		graph.Nodes[i].cumulative = total
+		graph.Nodes[i].Cumulative = graphComplexity(total)
+		state[i] = visited
+		return total
+	}
+	for i := range graph.Nodes {
+		cumulative(i)
+	}
+	return graph
+}
+
+func graphComplexity(complexity analyser.Complexity) GraphComplexity {
+	return GraphComplexity{Time: analyser.FormatComplexity(complexity.Time), Space: analyser.FormatComplexity(complexity.Space)}
+}
+
+// callTimes names the loops a call runs in, like O(len(items)), or its index when no input drives them
+func callTimes(call analyser.Call) string {
+	if len(call.Inputs) > 0 {
+		return analyser.FormatTerms([]analyser.Term{call.Inputs})
+	}
+	return analyser.FormatComplexity(call.Times)
+}
+
+func (node Node) label() string {
+	name := node.Name
+	if node.Receiver != "" {
+		name = node.Receiver + "." + name
+	}
+	return fmt.Sprintf("%s.%s\nown %s time, %s space\ncumulative %s time, %s space", node.Package, name, node.Own.Time, node.Own.Space, node.Cumulative.Time, node.Cumulative.Space)
+}
+
+func (edge Edge) label() string {
+	label := "called " + edge.Times + " times"
+	if edge.times == 0 {
+		label = "called once"
+	}
+	if len(edge.Lines) > 1 {
+		label += fmt.Sprintf(", %d call sites", len(edge.Lines))
+	}
+	return label
+}
+
+// heatColours fill nodes like the HTML report colours lines
+var heatColours = map[string]string{"heat-0": "#ffffff", "heat-1": "#fff8c5", "heat-2": "#ffd8b5", "heat-3": "#ffc1c0"}
+
+// WriteDOT writes the graph for Graphviz, nodes are coloured by their cumulative time and
+// edges get thicker the more times the call runs
+func WriteDOT(w io.Writer, graph Graph) error {
+	var out strings.Builder
+	out.WriteString("digraph funalyser {\n\trankdir=LR;\n\tnode [shape=box, style=filled, fontname=\"Helvetica\"];\n\tedge [fontname=\"Helvetica\", fontsize=10];\n")
+	for _, node := range graph.Nodes {
+		fmt.Fprintf(&out, "\t%s [label=%s, fillcolor=%q];\n", dotString(node.ID), dotString(node.label()), heatColours[heat(node.cumulative.Time)])
+	}
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(&out, "\t%s -> %s [label=%s, penwidth=%g];\n", dotString(edge.From), dotString(edge.To), dotString(edge.label()), 1+edge.times)
+	}
+	out.WriteString("}\n")
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// dotString quotes an ID or a label, new lines are kept as line breaks
+func dotString(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(text) + `"`
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart, for Markdown that renders them
+func WriteMermaid(w io.Writer, graph Graph) error {
+	var out strings.Builder
+	out.WriteString("flowchart LR\n")
+	ids := map[string]string{}
+	for i, node := range graph.Nodes {
+		ids[node.ID] = fmt.Sprintf("f%d", i)
+		fmt.Fprintf(&out, "    %s[\"%s\"]\n", ids[node.ID], mermaidString(node.label()))
+	}
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(&out, "    %s -->|\"%s\"| %s\n", ids[edge.From], mermaidString(edge.label()), ids[edge.To])
+	}
+	for i, node := range graph.Nodes {
+		fmt.Fprintf(&out, "    style f%d fill:%s\n", i, heatColours[heat(node.cumulative.Time)])
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// mermaidString escapes text for a quoted Mermaid label, where pipes would end edge labels
+func mermaidString(text string) string {
+	return strings.NewReplacer(`"`, "#quot;", "|", "#124;", "<", "#lt;", ">", "#gt;", "\n", "<br/>").Replace(text)
+}
+
+func WriteGraphJSON(w io.Writer, graph Graph) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(graph)
+}
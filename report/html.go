@@ -0,0 +1,460 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"go/scanner"
+	"go/token"
+	"html"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// WriteHTML writes a static site into dir: index.html summarises every package and links
+// to a page per file, whose source is highlighted and coloured by what each line contributes
+// to the complexity. Everything is inlined, the site opens from disk without a server
+func WriteHTML(dir string, funcs []analyser.FunctionInfo) error {
+	if err := os.MkdirAll(filepath.Join(dir, "files"), 0o755); err != nil {
+		return err
+	}
+
+	byFile := map[string][]analyser.FunctionInfo{}
+	for _, fn := range funcs {
+		byFile[fn.File] = append(byFile[fn.File], fn)
+	}
+	var files []fileSummary
+	for path, fileFuncs := range byFile {
+		sort.Slice(fileFuncs, func(i, j int) bool { return fileFuncs[i].Line < fileFuncs[j].Line })
+		file := fileSummary{Path: path, Page: "files/" + pageName(path), Package: fileFuncs[0].Package, Functions: len(fileFuncs)}
+		for _, fn := range fileFuncs {
+			file.Time = max(file.Time, fn.Complexity.Time)
+			file.Space = max(file.Space, fn.Complexity.Space)
+		}
+		if err := writeFilePage(filepath.Join(dir, file.Page), file, fileFuncs); err != nil {
+			return err
+		}
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return worse(files[i].Time, files[i].Space, files[i].Path, files[j].Time, files[j].Space, files[j].Path)
+	})
+
+	return writePage(filepath.Join(dir, "index.html"), indexTemplate, index{Packages: summarisePackages(funcs), Files: files})
+}
+
+type index struct {
+	Packages []packageSummary
+	Files    []fileSummary
+}
+
+type packageSummary struct {
+	Name          string
+	Dir           string
+	Files         int
+	Functions     int
+	Time          float32
+	Space         float32
+	LowConfidence int
+}
+
+type fileSummary struct {
+	Path      string
+	Page      string
+	Package   string
+	Functions int
+	Time      float32
+	Space     float32
+}
+
+// summarisePackages groups functions by the directory of their file, the Go package they belong to
+func summarisePackages(funcs []analyser.FunctionInfo) []packageSummary {
+	byDir := map[string]*packageSummary{}
+	files := map[string]map[string]bool{}
+	for _, fn := range funcs {
+		dir := filepath.Dir(fn.File)
+		summary, ok := byDir[dir]
+		if !ok {
+			summary = &packageSummary{Name: fn.Package, Dir: dir}
+			byDir[dir] = summary
+			files[dir] = map[string]bool{}
+		}
+		files[dir][fn.File] = true
+		summary.Functions++
+		summary.Time = max(summary.Time, fn.Complexity.Time)
+		summary.Space = max(summary.Space, fn.Complexity.Space)
+		if fn.Confidence == analyser.ConfidenceLow {
+			summary.LowConfidence++
+		}
+	}
+	var packages []packageSummary
+	for dir, summary := range byDir {
+		summary.Files = len(files[dir])
+		packages = append(packages, *summary)
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		return worse(packages[i].Time, packages[i].Space, packages[i].Dir, packages[j].Time, packages[j].Space, packages[j].Dir)
+	})
+	return packages
+}
+
+// worse orders by time, then space, from the worst, and by name when they are equal
+func worse(time float32, space float32, name string, otherTime float32, otherSpace float32, otherName string) bool {
+	if time != otherTime {
+		return time > otherTime
+	}
+	if space != otherSpace {
+		return space > otherSpace
+	}
+	return name < otherName
+}
+
+// pageName turns the path of a source file into the name of its page. Flattening the path
+// can make two paths alike, like a/b.go and a_b.go, so a hash of the path tells them apart
+func pageName(path string) string {
+	path = filepath.ToSlash(filepath.Clean(path))
+	name := strings.TrimPrefix(path, "/")
+	name = strings.ReplaceAll(strings.ReplaceAll(name, "../", "up_"), "/", "_")
+	sum := sha256.Sum256([]byte(path))
+	return name + "-" + hex.EncodeToString(sum[:4]) + ".html"
+}
+
+type filePage struct {
+	File      fileSummary
+	Functions []functionTrail
+	Lines     []sourceLine
+}
+
+type functionTrail struct {
+	ID          string
+	Name        string
+	Line        int
+	Time        string
+	Space       string
+	Heat        string
+	TimeByInput string
+	Confidence  analyser.Confidence
+	Evidence    []analyser.Evidence
+	Unresolved  []analyser.Unresolved
+	Diagnostics []analyser.Diagnostic
+}
+
+type sourceLine struct {
+	Number   int
+	Code     template.HTML
+	Heat     string
+	Title    string
+	Function string
+}
+
+func writeFilePage(path string, file fileSummary, funcs []analyser.FunctionInfo) error {
+	src, err := os.ReadFile(file.Path)
+	if err != nil {
+		return err
+	}
+	page := filePage{File: file}
+	contributions := map[int][]string{}
+	heats := map[int]float32{}
+	starts := map[int]string{}
+	unknown := map[int]bool{}
+	for _, fn := range funcs {
+		trail := functionTrail{
+			ID:          fmt.Sprintf("fn-%s-%d", fn.Name, fn.Line),
+			Name:        functionName(fn),
+			Line:        fn.Line,
+			Time:        analyser.FormatLowerBound(fn.Complexity.Time, fn.Unresolved),
+			Space:       analyser.FormatLowerBound(fn.Complexity.Space, fn.Unresolved),
+			Heat:        heat(max(fn.Complexity.Time, fn.Complexity.Space)),
+			TimeByInput: fn.TimeByInput,
+			Confidence:  fn.Confidence,
+			Evidence:    fn.Evidence,
+			Unresolved:  fn.Unresolved,
+			Diagnostics: fn.Diagnostics,
+		}
+		page.Functions = append(page.Functions, trail)
+		starts[fn.Line] = trail.ID
+		for _, evidence := range fn.Evidence {
+			heats[evidence.Line] = max(heats[evidence.Line], evidence.Time, evidence.Space)
+			contributions[evidence.Line] = append(contributions[evidence.Line], evidence.Message)
+		}
+		for _, unresolved := range fn.Unresolved {
+			unknown[unresolved.Line] = true
+			contributions[unresolved.Line] = append(contributions[unresolved.Line], "unknown: "+unresolved.Message)
+		}
+	}
+
+	for i, code := range highlight(file.Path, src) {
+		number := i + 1
+		line := sourceLine{Number: number, Code: code, Function: starts[number], Title: strings.Join(contributions[number], "\n")}
+		if index, ok := heats[number]; ok {
+			line.Heat = heat(index)
+		} else if unknown[number] {
+			line.Heat = "heat-unknown"
+		}
+		page.Lines = append(page.Lines, line)
+	}
+	return writePage(path, fileTemplate, page)
+}
+
+func functionName(fn analyser.FunctionInfo) string {
+	if fn.Receiver != "" {
+		return fn.Receiver + "." + fn.Name
+	}
+	return fn.Name
+}
+
+// heat is the colour class of a complexity index: constant, logarithmic to linear, up to
+// quadratic and beyond
+func heat(index float32) string {
+	switch {
+	case index == 0:
+		return "heat-0"
+	case index <= 1:
+		return "heat-1"
+	case index <= 2:
+		return "heat-2"
+	}
+	return "heat-3"
+}
+
+// highlight renders Go source as HTML, one line per element, with spans classed by token
+func highlight(path string, src []byte) []template.HTML {
+	classes := make([]string, len(src))
+	file := token.NewFileSet().AddFile(path, -1, len(src))
+	var goScanner scanner.Scanner
+	goScanner.Init(file, src, nil, scanner.ScanComments)
+	for {
+		pos, tok, literal := goScanner.Scan()
+		if tok == token.EOF {
+			break
+		}
+		class := ""
+		switch {
+		case tok == token.COMMENT:
+			class = "com"
+		case tok == token.STRING || tok == token.CHAR:
+			class = "str"
+		case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+			class = "num"
+		case tok.IsKeyword():
+			class = "kw"
+		}
+		if class == "" {
+			continue
+		}
+		start := file.Offset(pos)
+		end := min(start+len(literal), len(src))
+		if literal == "" {
+			end = min(start+len(tok.String()), len(src))
+		}
+		for offset := start; offset < end; offset++ {
+			classes[offset] = class
+		}
+	}
+
+	var lines []template.HTML
+	var line strings.Builder
+	for start := 0; start < len(src); {
+		if src[start] == '\n' {
+			lines = append(lines, template.HTML(line.String()))
+			line.Reset()
+			start++
+			continue
+		}
+		end := start
+		for end < len(src) && src[end] != '\n' && classes[end] == classes[start] {
+			end++
+		}
+		text := html.EscapeString(string(src[start:end]))
+		if classes[start] != "" {
+			fmt.Fprintf(&line, `<span class="%s">%s</span>`, classes[start], text)
+		} else {
+			line.WriteString(text)
+		}
+		start = end
+	}
+	if line.Len() > 0 {
+		lines = append(lines, template.HTML(line.String()))
+	}
+	return lines
+}
+
+func writePage(path string, page *template.Template, data any) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := page.Execute(out, data); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+var functions = template.FuncMap{
+	"complexity": analyser.FormatComplexity,
+	"heat":       heat,
+	"contribution": func(evidence analyser.Evidence) string {
+		return heat(max(evidence.Time, evidence.Space))
+	},
+}
+
+const style = `<style>
+body { font-family: -apple-system, "Segoe UI", sans-serif; margin: 0; color: #1f2328; }
+header { padding: 12px 24px; background: #24292f; color: #fff; }
+header a { color: #9ecbff; }
+main { padding: 16px 24px; }
+table.summary { border-collapse: collapse; margin-bottom: 24px; }
+table.summary th, table.summary td { padding: 4px 12px; border-bottom: 1px solid #d0d7de; text-align: left; }
+table.summary th { cursor: pointer; user-select: none; background: #f6f8fa; }
+table.summary th[data-order="desc"]::after { content: " ▼"; }
+table.summary th[data-order="asc"]::after { content: " ▲"; }
+.layout { display: flex; gap: 24px; align-items: flex-start; }
+nav { min-width: 260px; position: sticky; top: 0; max-height: 100vh; overflow: auto; }
+nav ul { list-style: none; padding: 0; }
+nav li { margin: 4px 0; }
+.trail { display: none; border: 1px solid #d0d7de; border-radius: 6px; padding: 8px 12px; margin-top: 12px; font-size: 14px; }
+.trail.open { display: block; }
+.trail ul { padding-left: 18px; list-style: disc; }
+pre.source { margin: 0; font: 13px/1.5 ui-monospace, monospace; flex: 1; overflow-x: auto; }
+.line { display: block; }
+.line .number { display: inline-block; width: 4em; color: #8c959f; text-align: right; padding-right: 12px; text-decoration: none; }
+.line:target { outline: 2px solid #0969da; }
+.kw { color: #cf222e; } .str { color: #0a3069; } .com { color: #6e7781; font-style: italic; } .num { color: #0550ae; }
+.heat-0 { } .heat-1 { background: #fff8c5; } .heat-2 { background: #ffd8b5; } .heat-3 { background: #ffc1c0; }
+.heat-unknown { background: repeating-linear-gradient(45deg, #f6f8fa, #f6f8fa 4px, #eaeef2 4px, #eaeef2 8px); }
+.badge { padding: 0 6px; border-radius: 4px; }
+</style>`
+
+const sortScript = `<script>
+document.querySelectorAll("table.summary th").forEach(function (header, column) {
+  header.addEventListener("click", function () {
+    var table = header.closest("table");
+    var descending = header.dataset.order !== "desc";
+    table.querySelectorAll("th").forEach(function (other) { delete other.dataset.order; });
+    header.dataset.order = descending ? "desc" : "asc";
+    var rows = Array.from(table.tBodies[0].rows);
+    rows.sort(function (a, b) {
+      var x = a.cells[column].dataset.value || a.cells[column].textContent;
+      var y = b.cells[column].dataset.value || b.cells[column].textContent;
+      var order = isNaN(x) || isNaN(y) ? x.localeCompare(y) : x - y;
+      return descending ? -order : order;
+    });
+    rows.forEach(function (row) { table.tBodies[0].appendChild(row); });
+  });
+});
+</script>`
+
+// trailScript opens the evidence trail of the function in the URL, and keeps it open while
+// following its links to source lines
+const trailScript = `<script>
+function openTrail() {
+  var target = document.getElementById(location.hash.slice(1));
+  if (!target || !target.classList.contains("trail")) {
+    return;
+  }
+  document.querySelectorAll(".trail.open").forEach(function (trail) { trail.classList.remove("open"); });
+  target.classList.add("open");
+}
+window.addEventListener("hashchange", openTrail);
+openTrail();
+</script>`
+
+var indexTemplate = template.Must(template.New("index").Funcs(functions).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>funalyser report</title>
+` + style + `
+</head>
+<body>
+<header><strong>funalyser</strong> report</header>
+<main>
+<h2>Packages</h2>
+<table class="summary">
+<thead><tr><th>Package</th><th>Directory</th><th>Files</th><th>Functions</th><th data-order="desc">Worst time</th><th>Worst space</th><th>Low confidence</th></tr></thead>
+<tbody>
+{{- range .Packages}}
+<tr><td>{{.Name}}</td><td>{{.Dir}}</td><td>{{.Files}}</td><td>{{.Functions}}</td><td class="{{heat .Time}}" data-value="{{.Time}}">{{complexity .Time}}</td><td class="{{heat .Space}}" data-value="{{.Space}}">{{complexity .Space}}</td><td>{{.LowConfidence}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+<h2>Files</h2>
+<table class="summary">
+<thead><tr><th>File</th><th>Package</th><th>Functions</th><th data-order="desc">Worst time</th><th>Worst space</th></tr></thead>
+<tbody>
+{{- range .Files}}
+<tr><td><a href="{{.Page}}">{{.Path}}</a></td><td>{{.Package}}</td><td>{{.Functions}}</td><td class="{{heat .Time}}" data-value="{{.Time}}">{{complexity .Time}}</td><td class="{{heat .Space}}" data-value="{{.Space}}">{{complexity .Space}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+</main>
+` + sortScript + `
+</body>
+</html>
+`))
+
+var fileTemplate = template.Must(template.New("file").Funcs(functions).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.File.Path}} · funalyser report</title>
+` + style + `
+</head>
+<body>
+<header><a href="../index.html">funalyser report</a> / {{.File.Path}}</header>
+<main class="layout">
+<nav>
+<h3>Functions</h3>
+<ul>
+{{- range .Functions}}
+<li><a href="#{{.ID}}">{{.Name}}</a> <span class="badge {{.Heat}}">{{.Time}}</span></li>
+{{- end}}
+</ul>
+{{- range .Functions}}
+<section class="trail" id="{{.ID}}">
+<h4><a href="#L{{.Line}}">{{.Name}}</a></h4>
+<p>Time {{.Time}}{{if .TimeByInput}}, {{.TimeByInput}}{{end}}<br>Space {{.Space}}<br>Confidence {{.Confidence}}</p>
+{{- if .Evidence}}
+<p>Counted</p>
+<ul>
+{{- range .Evidence}}
+<li class="{{contribution .}}"><a href="#L{{.Line}}">line {{.Line}}</a> {{.Message}}</li>
+{{- end}}
+</ul>
+{{- else}}
+<p>Nothing grows with an input</p>
+{{- end}}
+{{- if .Unresolved}}
+<p>Not understood</p>
+<ul>
+{{- range .Unresolved}}
+<li class="heat-unknown"><a href="#L{{.Line}}">line {{.Line}}</a> {{.Kind}}: {{.Message}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Diagnostics}}
+<p>Findings</p>
+<ul>
+{{- range .Diagnostics}}
+<li><a href="#L{{.Line}}">line {{.Line}}</a> [{{.Rule}}] {{.Message}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</section>
+{{- end}}
+</nav>
+<pre class="source">
+{{- range .Lines -}}
+<span class="line {{.Heat}}" id="L{{.Number}}"{{if .Title}} title="{{.Title}}"{{end}}><a class="number" href="{{if .Function}}#{{.Function}}{{else}}#L{{.Number}}{{end}}">{{.Number}}</a>{{.Code}}</span>
+{{- end -}}
+</pre>
+</main>
+` + trailScript + `
+</body>
+</html>
+`))
@@ -0,0 +1,377 @@
+package report
+
+import (
+	_ "embed"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"github.com/DanyloPiatyhorets/funalyser/baseline"
+	"github.com/DanyloPiatyhorets/funalyser/verify"
+)
+
+// SchemaVersion is bumped whenever the JSON output changes in a way that is not backward
+// compatible, like removing or renaming a field. New optional fields do not bump it
+const SchemaVersion = 1
+
+// Schema is the JSON Schema of the JSON outputs: Document, Changes, Check, Verification,
+// Regressions and Graph
+//
+//go:embed schema.json
+var Schema []byte
+
+// Document is the JSON output of an analysis. Its fields are documented by Schema and do not
+// follow the internal structs, so that they can change without breaking consumers
+type Document struct {
+	SchemaVersion int        `json:"schemaVersion"`
+	Functions     []Function `json:"functions"`
+	// Diagnostics are raised on whole files, like syntax errors
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+type Function struct {
+	Name        string     `json:"name"`
+	Receiver    string     `json:"receiver,omitempty"`
+	Package     string     `json:"package"`
+	Position    Range      `json:"position"`
+	Fingerprint string     `json:"fingerprint"`
+	Time        Complexity `json:"time"`
+	// TimeByInput names the inputs driving the time complexity instead of n
+	TimeByInput Complexity         `json:"timeByInput"`
+	Space       Complexity         `json:"space"`
+	FanOut      int                `json:"fanOut"`
+	Confidence  string             `json:"confidence"`
+	Evidence    []Evidence         `json:"evidence"`
+	Unresolved  []Unresolved       `json:"unresolved"`
+	Diagnostics []Diagnostic       `json:"diagnostics"`
+	Metrics     map[string]float64 `json:"metrics,omitempty"`
+}
+
+// Complexity is a complexity class both as big O notation and as an expression tree
+type Complexity struct {
+	Class string `json:"class"`
+	// LowerBound is set when parts of the function were not understood, the real complexity
+	// may be higher
+	LowerBound bool       `json:"lowerBound"`
+	Expression Expression `json:"expression"`
+}
+
+const (
+	ExpressionConstant = "constant"
+	ExpressionVariable = "variable"
+	ExpressionPower    = "power"
+	ExpressionLog      = "log"
+	ExpressionProduct  = "product"
+	ExpressionSum      = "sum"
+)
+
+// Expression is a node of a complexity expression: a constant Value, a variable Name, the
+// power Value of its operand, the logarithm of its operand, or the product or sum of its operands
+type Expression struct {
+	Kind     string       `json:"kind"`
+	Value    int          `json:"value,omitempty"`
+	Name     string       `json:"name,omitempty"`
+	Operands []Expression `json:"operands,omitempty"`
+}
+
+// Range is where a function is, lines count from 1
+type Range struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	EndLine int    `json:"endLine"`
+}
+
+type Position struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+type Evidence struct {
+	Kind    string `json:"kind"`
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+	Time    string `json:"time"`
+	Space   string `json:"space"`
+}
+
+type Unresolved struct {
+	Kind    string `json:"kind"`
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+type Diagnostic struct {
+	Rule     string   `json:"rule"`
+	Message  string   `json:"message"`
+	Position Position `json:"position"`
+	Fixes    []Fix    `json:"fixes"`
+}
+
+type Fix struct {
+	Message string     `json:"message"`
+	Edits   []TextEdit `json:"edits"`
+}
+
+// TextEdit replaces the text between two positions, columns count bytes from 1
+type TextEdit struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"endLine"`
+	EndColumn int    `json:"endColumn"`
+	NewText   string `json:"newText"`
+}
+
+// Changes is the JSON output of analyse --since, the functions changed since a revision and
+// the functions calling them
+type Changes struct {
+	SchemaVersion int      `json:"schemaVersion"`
+	Changes       []Change `json:"changes"`
+}
+
+type Change struct {
+	Function Function `json:"function"`
+	// Reason is "changed" or the function it calls that changed, like "calls main.parse"
+	Reason string `json:"reason"`
+	// Before is the complexity at the revision, missing for functions that did not exist then
+	Before *Complexities `json:"before,omitempty"`
+}
+
+type Complexities struct {
+	Time  Complexity `json:"time"`
+	Space Complexity `json:"space"`
+}
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Check is the JSON output of check, the findings it fails on or warns about
+type Check struct {
+	SchemaVersion int       `json:"schemaVersion"`
+	Findings      []Finding `json:"findings"`
+}
+
+// Finding is a diagnostic along with the function it was raised on, empty for whole files,
+// and its severity: check fails on errors, warnings are advisory
+type Finding struct {
+	Diagnostic
+	Function string `json:"function,omitempty"`
+	Severity string `json:"severity"`
+}
+
+// Verification is the JSON output of verify, the static complexity of a function against the
+// one fitted to its benchmarks
+type Verification struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	Function      string       `json:"function"`
+	Static        Complexities `json:"static"`
+	Measured      Complexities `json:"measured"`
+	Samples       []Sample     `json:"samples"`
+	TimeAgrees    bool         `json:"timeAgrees"`
+	SpaceAgrees   bool         `json:"spaceAgrees"`
+}
+
+// Regressions is the JSON output of baseline diff, the functions whose complexity got worse
+// than in the baseline
+type Regressions struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	Regressions   []Regression `json:"regressions"`
+}
+
+type Regression struct {
+	Name     string   `json:"name"`
+	Receiver string   `json:"receiver,omitempty"`
+	Package  string   `json:"package"`
+	Position Position `json:"position"`
+	// Match is how the function was paired with its baseline entry: exact, renamed or moved
+	Match string `json:"match"`
+	// Previous is the baseline entry of a renamed or moved function, like "cmd.parse"
+	Previous string       `json:"previous,omitempty"`
+	Before   Complexities `json:"before"`
+	After    Complexities `json:"after"`
+}
+
+type Sample struct {
+	N           int     `json:"n"`
+	NsPerOp     float64 `json:"nsPerOp"`
+	BytesPerOp  float64 `json:"bytesPerOp"`
+	AllocsPerOp float64 `json:"allocsPerOp"`
+}
+
+// NewDocument converts analysed functions and the diagnostics raised on their files
+func NewDocument(funcs []analyser.FunctionInfo, fileDiagnostics []analyser.Diagnostic) Document {
+	document := Document{SchemaVersion: SchemaVersion, Functions: []Function{}, Diagnostics: newDiagnostics(fileDiagnostics)}
+	for _, fn := range funcs {
+		document.Functions = append(document.Functions, NewFunction(fn))
+	}
+	return document
+}
+
+func NewFunction(fn analyser.FunctionInfo) Function {
+	lowerBound := len(fn.Unresolved) > 0
+	function := Function{
+		Name:        fn.Name,
+		Receiver:    fn.Receiver,
+		Package:     fn.Package,
+		Position:    Range{File: fn.File, Line: fn.Line, EndLine: fn.EndLine},
+		Fingerprint: fn.Fingerprint,
+		Time:        Complexity{Class: analyser.FormatComplexity(fn.Complexity.Time), LowerBound: lowerBound, Expression: indexExpression(fn.Complexity.Time, "n")},
+		TimeByInput: Complexity{Class: analyser.FormatTerms(fn.Terms), LowerBound: lowerBound, Expression: termsExpression(fn.Terms)},
+		Space:       Complexity{Class: analyser.FormatComplexity(fn.Complexity.Space), LowerBound: lowerBound, Expression: indexExpression(fn.Complexity.Space, "n")},
+		FanOut:      fn.FanOut,
+		Confidence:  string(fn.Confidence),
+		Evidence:    []Evidence{},
+		Unresolved:  []Unresolved{},
+		Diagnostics: newDiagnostics(fn.Diagnostics),
+		Metrics:     fn.Metrics,
+	}
+	for _, evidence := range fn.Evidence {
+		function.Evidence = append(function.Evidence, Evidence{
+			Kind:    evidence.Kind,
+			Line:    evidence.Line,
+			Message: evidence.Message,
+			Time:    analyser.FormatComplexity(evidence.Time),
+			Space:   analyser.FormatComplexity(evidence.Space),
+		})
+	}
+	for _, unresolved := range fn.Unresolved {
+		function.Unresolved = append(function.Unresolved, Unresolved{Kind: unresolved.Kind, Line: unresolved.Line, Message: unresolved.Message})
+	}
+	return function
+}
+
+// NewChange converts a changed function and what it was at the revision, before is nil for
+// new functions
+func NewChange(fn analyser.FunctionInfo, reason string, before *analyser.FunctionInfo) Change {
+	change := Change{Function: NewFunction(fn), Reason: reason}
+	if before != nil {
+		complexities := newComplexities(before.Complexity, len(before.Unresolved) > 0)
+		change.Before = &complexities
+	}
+	return change
+}
+
+func NewCheck(diagnostics []analyser.Diagnostic) Check {
+	check := Check{SchemaVersion: SchemaVersion, Findings: []Finding{}}
+	for i, diagnostic := range newDiagnostics(diagnostics) {
+		check.Findings = append(check.Findings, Finding{Diagnostic: diagnostic, Function: diagnostics[i].Function, Severity: Severity(diagnostics[i])})
+	}
+	return check
+}
+
+// Severity is "warning" for advisory diagnostics and "error" for those check fails on
+func Severity(diagnostic analyser.Diagnostic) string {
+	if diagnostic.Advisory() {
+		return SeverityWarning
+	}
+	return SeverityError
+}
+
+func NewVerification(result *verify.Result) Verification {
+	verification := Verification{
+		SchemaVersion: SchemaVersion,
+		Function:      result.Function,
+		Static:        newComplexities(result.Static, false),
+		Measured:      newComplexities(result.Measured, false),
+		Samples:       []Sample{},
+		TimeAgrees:    result.TimeAgrees,
+		SpaceAgrees:   result.SpaceAgrees,
+	}
+	for _, sample := range result.Samples {
+		verification.Samples = append(verification.Samples, Sample(sample))
+	}
+	return verification
+}
+
+func NewRegressions(regressions []baseline.Regression) Regressions {
+	converted := Regressions{SchemaVersion: SchemaVersion, Regressions: []Regression{}}
+	for _, regression := range regressions {
+		previous := ""
+		if regression.Match != baseline.MatchExact {
+			previous = regression.Old.Key()
+		}
+		converted.Regressions = append(converted.Regressions, Regression{
+			Name:     regression.New.Name,
+			Receiver: regression.New.Receiver,
+			Package:  regression.New.Package,
+			Position: Position{File: regression.New.File, Line: regression.New.Line},
+			Match:    regression.Match,
+			Previous: previous,
+			Before:   newComplexities(analyser.Complexity{Time: regression.Old.Time, Space: regression.Old.Space}, false),
+			After:    newComplexities(analyser.Complexity{Time: regression.New.Time, Space: regression.New.Space}, false),
+		})
+	}
+	return converted
+}
+
+func newComplexities(complexity analyser.Complexity, lowerBound bool) Complexities {
+	return Complexities{
+		Time:  Complexity{Class: analyser.FormatComplexity(complexity.Time), LowerBound: lowerBound, Expression: indexExpression(complexity.Time, "n")},
+		Space: Complexity{Class: analyser.FormatComplexity(complexity.Space), LowerBound: lowerBound, Expression: indexExpression(complexity.Space, "n")},
+	}
+}
+
+func newDiagnostics(diagnostics []analyser.Diagnostic) []Diagnostic {
+	converted := []Diagnostic{}
+	for _, diagnostic := range diagnostics {
+		fixes := []Fix{}
+		for _, fix := range diagnostic.Fixes {
+			edits := []TextEdit{}
+			for _, edit := range fix.TextEdits {
+				edits = append(edits, TextEdit(edit))
+			}
+			fixes = append(fixes, Fix{Message: fix.Message, Edits: edits})
+		}
+		converted = append(converted, Diagnostic{
+			Rule:     diagnostic.Rule,
+			Message:  diagnostic.Message,
+			Position: Position{File: diagnostic.File, Line: diagnostic.Line},
+			Fixes:    fixes,
+		})
+	}
+	return converted
+}
+
+// indexExpression is the expression of a complexity index over a variable, like FormatComplexity
+func indexExpression(index float32, variable string) Expression {
+	whole := int(index)
+	var factors []Expression
+	switch {
+	case whole == 1:
+		factors = append(factors, Expression{Kind: ExpressionVariable, Name: variable})
+	case whole > 1:
+		factors = append(factors, Expression{Kind: ExpressionPower, Value: whole, Operands: []Expression{{Kind: ExpressionVariable, Name: variable}}})
+	}
+	if index != float32(whole) {
+		factors = append(factors, Expression{Kind: ExpressionLog, Operands: []Expression{{Kind: ExpressionVariable, Name: variable}}})
+	}
+	switch len(factors) {
+	case 0:
+		return Expression{Kind: ExpressionConstant, Value: 1}
+	case 1:
+		return factors[0]
+	}
+	return Expression{Kind: ExpressionProduct, Operands: factors}
+}
+
+// termsExpression is the sum of the products of the factors of terms, like FormatTerms
+func termsExpression(terms []analyser.Term) Expression {
+	var sum []Expression
+	for _, term := range terms {
+		var product []Expression
+		for _, factor := range term {
+			product = append(product, indexExpression(factor.Index, factor.Input))
+		}
+		if len(product) == 1 {
+			sum = append(sum, product[0])
+		} else {
+			sum = append(sum, Expression{Kind: ExpressionProduct, Operands: product})
+		}
+	}
+	switch len(sum) {
+	case 0:
+		return Expression{Kind: ExpressionConstant, Value: 1}
+	case 1:
+		return sum[0]
+	}
+	return Expression{Kind: ExpressionSum, Operands: sum}
+}
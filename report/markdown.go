@@ -0,0 +1,114 @@
+package report
+
+import (
+	"fmt"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"github.com/DanyloPiatyhorets/funalyser/baseline"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes a GitHub-flavoured summary of the analysis, compact enough to be
+// posted as a pull request comment: a table of the functions followed by their evidence in
+// collapsed sections. The table gets a column comparing every function with old when it is
+// not nil, root is the directory the packages of old are relative to
+func WriteMarkdown(w io.Writer, funcs []analyser.FunctionInfo, old *baseline.Baseline, root string) error {
+	changes := map[string]baseline.Change{}
+	if old != nil {
+		for _, change := range baseline.Match(*old, baseline.New(root, funcs)) {
+			changes[change.New.Key()] = change
+		}
+	}
+
+	var out strings.Builder
+	out.WriteString("### funalyser\n\n")
+	if len(funcs) == 0 {
+		out.WriteString("No functions analysed\n")
+		_, err := io.WriteString(w, out.String())
+		return err
+	}
+	header := []string{"Function", "File", "Time", "Space", "Fan-out"}
+	if old != nil {
+		header = append(header, "Change")
+	}
+	writeRow(&out, header...)
+	writeRow(&out, strings.Split(strings.Repeat("---,", len(header)-1)+"---", ",")...)
+	for _, fn := range funcs {
+		row := []string{
+			"`" + functionName(fn) + "`",
+			fmt.Sprintf("`%s:%d`", fn.File, fn.Line),
+			lowerBound(fn.Complexity.Time, fn.Unresolved),
+			lowerBound(fn.Complexity.Space, fn.Unresolved),
+			fmt.Sprint(fn.FanOut),
+		}
+		if old != nil {
+			row = append(row, formatChange(changes[baseline.NewEntry(root, fn).Key()]))
+		}
+		writeRow(&out, row...)
+	}
+
+	for _, fn := range funcs {
+		if len(fn.Evidence) == 0 && len(fn.Unresolved) == 0 && len(fn.Diagnostics) == 0 {
+			continue
+		}
+		fmt.Fprintf(&out, "\n<details>\n<summary><code>%s</code> %s</summary>\n\n", functionName(fn), escapeHTML(fn.TimeByInput))
+		for _, evidence := range fn.Evidence {
+			fmt.Fprintf(&out, "- line %d: %s\n", evidence.Line, evidence.Message)
+		}
+		for _, unresolved := range fn.Unresolved {
+			fmt.Fprintf(&out, "- ❔ line %d: %s\n", unresolved.Line, unresolved.Message)
+		}
+		for _, diagnostic := range fn.Diagnostics {
+			fmt.Fprintf(&out, "- ⚠️ line %d: %s `%s`\n", diagnostic.Line, diagnostic.Message, diagnostic.Rule)
+		}
+		out.WriteString("\n</details>\n")
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// writeRow writes a row of a table, pipes in cells would end them early
+func writeRow(out *strings.Builder, cells ...string) {
+	for i := range cells {
+		cells[i] = strings.ReplaceAll(cells[i], "|", `\|`)
+	}
+	fmt.Fprintf(out, "| %s |\n", strings.Join(cells, " | "))
+}
+
+// lowerBound is the complexity, prefixed with ≥ when parts of the function were not understood
+func lowerBound(index float32, unresolved []analyser.Unresolved) string {
+	if len(unresolved) > 0 {
+		return "≥ " + analyser.FormatComplexity(index)
+	}
+	return analyser.FormatComplexity(index)
+}
+
+// formatChange compares a function with its baseline entry, "=" when neither time nor space changed
+func formatChange(change baseline.Change) string {
+	if change.Match == "" {
+		return "new"
+	}
+	var parts []string
+	for _, complexity := range []struct {
+		name     string
+		old, new float32
+	}{{"time", change.Old.Time, change.New.Time}, {"space", change.Old.Space, change.New.Space}} {
+		switch {
+		case complexity.new > complexity.old:
+			parts = append(parts, fmt.Sprintf("🔺 %s %s → %s", complexity.name, analyser.FormatComplexity(complexity.old), analyser.FormatComplexity(complexity.new)))
+		case complexity.new < complexity.old:
+			parts = append(parts, fmt.Sprintf("🔻 %s %s → %s", complexity.name, analyser.FormatComplexity(complexity.old), analyser.FormatComplexity(complexity.new)))
+		}
+	}
+	if len(parts) == 0 {
+		return "="
+	}
+	if change.Match != baseline.MatchExact {
+		parts = append(parts, change.Match+" from `"+change.Old.Key()+"`")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func escapeHTML(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
@@ -0,0 +1,109 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"io"
+	"strconv"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatTSV    = "tsv"
+	FormatNDJSON = "ndjson"
+)
+
+// ColumnsVersion is bumped whenever Columns change. Columns are only ever added at the end,
+// so that pipelines reading older versions keep working
+const ColumnsVersion = 1
+
+// Columns are the fields of a row, in the order of the csv and tsv outputs and with the
+// names of the ndjson fields
+var Columns = []string{
+	"version", "package", "file", "receiver", "function", "line", "end_line",
+	"time", "space", "fan_out", "confidence", "lines_of_code",
+}
+
+// Row is a function as a flat record for spreadsheets and warehouses
+type Row struct {
+	Version     int    `json:"version"`
+	Package     string `json:"package"`
+	File        string `json:"file"`
+	Receiver    string `json:"receiver"`
+	Function    string `json:"function"`
+	Line        int    `json:"line"`
+	EndLine     int    `json:"end_line"`
+	Time        string `json:"time"`
+	Space       string `json:"space"`
+	FanOut      int    `json:"fan_out"`
+	Confidence  string `json:"confidence"`
+	LinesOfCode int    `json:"lines_of_code"`
+}
+
+func NewRow(fn analyser.FunctionInfo) Row {
+	return Row{
+		Version:     ColumnsVersion,
+		Package:     fn.Package,
+		File:        fn.File,
+		Receiver:    fn.Receiver,
+		Function:    fn.Name,
+		Line:        fn.Line,
+		EndLine:     fn.EndLine,
+		Time:        analyser.FormatComplexity(fn.Complexity.Time),
+		Space:       analyser.FormatComplexity(fn.Complexity.Space),
+		FanOut:      fn.FanOut,
+		Confidence:  string(fn.Confidence),
+		LinesOfCode: fn.EndLine - fn.Line + 1,
+	}
+}
+
+func (row Row) values() []string {
+	return []string{
+		strconv.Itoa(row.Version), row.Package, row.File, row.Receiver, row.Function,
+		strconv.Itoa(row.Line), strconv.Itoa(row.EndLine), row.Time, row.Space,
+		strconv.Itoa(row.FanOut), row.Confidence, strconv.Itoa(row.LinesOfCode),
+	}
+}
+
+// RowWriter writes functions one row or line at a time, each is flushed as soon as it is
+// written so that output streams while the analysis runs
+type RowWriter struct {
+	csv  *csv.Writer
+	json *json.Encoder
+}
+
+// NewRowWriter starts csv, tsv or ndjson output, the csv and tsv outputs with their header
+func NewRowWriter(w io.Writer, format string) (*RowWriter, error) {
+	switch format {
+	case FormatCSV, FormatTSV:
+		writer := csv.NewWriter(w)
+		if format == FormatTSV {
+			writer.Comma = '\t'
+		}
+		rowWriter := &RowWriter{csv: writer}
+		return rowWriter, rowWriter.writeRecord(Columns)
+	case FormatNDJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		return &RowWriter{json: encoder}, nil
+	}
+	return nil, fmt.Errorf("unknown row format %q, use %s, %s or %s", format, FormatCSV, FormatTSV, FormatNDJSON)
+}
+
+func (rowWriter *RowWriter) Write(fn analyser.FunctionInfo) error {
+	row := NewRow(fn)
+	if rowWriter.json != nil {
+		return rowWriter.json.Encode(row)
+	}
+	return rowWriter.writeRecord(row.values())
+}
+
+func (rowWriter *RowWriter) writeRecord(record []string) error {
+	if err := rowWriter.csv.Write(record); err != nil {
+		return err
+	}
+	rowWriter.csv.Flush()
+	return rowWriter.csv.Error()
+}
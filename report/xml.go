@@ -0,0 +1,185 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"io"
+)
+
+const (
+	FormatJUnit      = "junit"
+	FormatCheckstyle = "checkstyle"
+)
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string         `xml:"name,attr"`
+	ClassName string         `xml:"classname,attr"`
+	File      string         `xml:"file,attr"`
+	Line      int            `xml:"line,attr"`
+	Failures  []junitFailure `xml:"failure"`
+	SystemOut string         `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Type    string `xml:"type,attr"`
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes a test suite per file and a test case per function, failing with the
+// diagnostics raised on it, like threshold breaches and expect mismatches. Advisory diagnostics
+// do not fail it and go to its system-out. Diagnostics raised on a whole file go to a test case
+// named after the file
+func WriteJUnit(w io.Writer, funcs []analyser.FunctionInfo, diagnostics []analyser.Diagnostic) error {
+	suites := junitSuites{Name: "funalyser"}
+	suiteOf := map[string]int{}
+	suite := func(file string) *junitSuite {
+		if _, ok := suiteOf[file]; !ok {
+			suiteOf[file] = len(suites.Suites)
+			suites.Suites = append(suites.Suites, junitSuite{Name: file})
+		}
+		return &suites.Suites[suiteOf[file]]
+	}
+
+	owners := owners(funcs, diagnostics)
+	caseOf := map[int]int{}
+	for i, fn := range funcs {
+		fileSuite := suite(fn.File)
+		caseOf[i] = len(fileSuite.Cases)
+		fileSuite.Cases = append(fileSuite.Cases, junitCase{Name: functionName(fn), ClassName: fn.File, File: fn.File, Line: fn.Line})
+	}
+	fileCases := map[string]int{}
+	for i, diagnostic := range diagnostics {
+		fileSuite := suite(diagnostic.File)
+		index, ok := caseOf[owners[i]]
+		if owners[i] < 0 {
+			index, ok = fileCases[diagnostic.File]
+			if !ok {
+				index = len(fileSuite.Cases)
+				fileCases[diagnostic.File] = index
+				fileSuite.Cases = append(fileSuite.Cases, junitCase{Name: diagnostic.File, ClassName: diagnostic.File, File: diagnostic.File, Line: diagnostic.Line})
+			}
+		}
+		text := fmt.Sprintf("%s:%d: %s [%s]", diagnostic.File, diagnostic.Line, diagnostic.Message, diagnostic.Rule)
+		testCase := &fileSuite.Cases[index]
+		if diagnostic.Advisory() {
+			if testCase.SystemOut != "" {
+				testCase.SystemOut += "\n"
+			}
+			testCase.SystemOut += text
+			continue
+		}
+		testCase.Failures = append(testCase.Failures, junitFailure{Type: diagnostic.Rule, Message: diagnostic.Message, Text: text})
+	}
+
+	for i := range suites.Suites {
+		fileSuite := &suites.Suites[i]
+		fileSuite.Tests = len(fileSuite.Cases)
+		for _, testCase := range fileSuite.Cases {
+			if len(testCase.Failures) > 0 {
+				fileSuite.Failures++
+			}
+		}
+		suites.Tests += fileSuite.Tests
+		suites.Failures += fileSuite.Failures
+	}
+	return writeXML(w, suites)
+}
+
+type checkstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// WriteCheckstyle writes every analysed file with the diagnostics raised in it, errors or
+// warnings for advisory ones, whose source is the rule, like funalyser.threshold
+func WriteCheckstyle(w io.Writer, funcs []analyser.FunctionInfo, diagnostics []analyser.Diagnostic) error {
+	report := checkstyleReport{Version: "8.0"}
+	fileOf := map[string]int{}
+	file := func(name string) *checkstyleFile {
+		if _, ok := fileOf[name]; !ok {
+			fileOf[name] = len(report.Files)
+			report.Files = append(report.Files, checkstyleFile{Name: name})
+		}
+		return &report.Files[fileOf[name]]
+	}
+	for _, fn := range funcs {
+		file(fn.File)
+	}
+	for _, diagnostic := range diagnostics {
+		message := diagnostic.Message
+		if diagnostic.Function != "" {
+			message = diagnostic.Function + ": " + message
+		}
+		checkstyle := file(diagnostic.File)
+		checkstyle.Errors = append(checkstyle.Errors, checkstyleError{
+			Line:     diagnostic.Line,
+			Severity: Severity(diagnostic),
+			Message:  message,
+			Source:   "funalyser." + diagnostic.Rule,
+		})
+	}
+	return writeXML(w, report)
+}
+
+// owners finds the function each diagnostic was raised on, -1 for diagnostics on whole files.
+// Directives sit above their function, so a diagnostic belongs to the first function of its
+// file with its name that does not end before it
+func owners(funcs []analyser.FunctionInfo, diagnostics []analyser.Diagnostic) []int {
+	found := make([]int, len(diagnostics))
+	for i, diagnostic := range diagnostics {
+		found[i] = -1
+		if diagnostic.Function == "" {
+			continue
+		}
+		for j, fn := range funcs {
+			if fn.File == diagnostic.File && fn.Name == diagnostic.Function && diagnostic.Line <= fn.EndLine {
+				found[i] = j
+				break
+			}
+		}
+	}
+	return found
+}
+
+func writeXML(w io.Writer, document any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,71 @@
+package test
+
+import (
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"github.com/DanyloPiatyhorets/funalyser/baseline"
+	"testing"
+)
+
+func TestBaselineDiff(t *testing.T) {
+	old := baseline.Baseline{Functions: []baseline.Entry{
+		{Package: "sorting", Name: "BubbleSort", Fingerprint: "a", Time: 2},
+		{Package: "sorting", Name: "merge", Fingerprint: "b", Time: 1, Space: 1},
+		{Package: "store", Receiver: "Cache", Name: "Get", Fingerprint: "c", Time: 0},
+		{Package: "util", Name: "reverse", Fingerprint: "d", Time: 1},
+		{Package: "util", Name: "unchanged", Fingerprint: "e", Time: 1},
+	}}
+	current := baseline.Baseline{Functions: []baseline.Entry{
+		// got worse in place
+		{Package: "sorting", Name: "BubbleSort", Fingerprint: "a2", Time: 3},
+		// renamed, same body
+		{Package: "sorting", Name: "mergeHalves", Fingerprint: "b", Time: 1, Space: 1},
+		// moved to another package and got worse
+		{Package: "cache", Receiver: "Cache", Name: "Get", Fingerprint: "c2", Time: 1},
+		// improved
+		{Package: "util", Name: "reverse", Fingerprint: "d2", Time: 0.5},
+		{Package: "util", Name: "unchanged", Fingerprint: "e", Time: 1},
+		// brand new functions are never regressions
+		{Package: "util", Name: "fresh", Fingerprint: "f", Time: 2},
+	}}
+
+	regressions := baseline.Diff(old, current)
+
+	expected := map[string]string{
+		"sorting.BubbleSort": baseline.MatchExact,
+		"cache.Cache.Get":    baseline.MatchMoved,
+	}
+	if len(regressions) != len(expected) {
+		t.Fatalf("expected %d regressions, got %d: %+v", len(expected), len(regressions), regressions)
+	}
+	for _, regression := range regressions {
+		want, ok := expected[regression.New.Key()]
+		if !ok {
+			t.Errorf("unexpected regression for %s", regression.New.Key())
+		} else if regression.Match != want {
+			t.Errorf("match for %s: expected %s, got %s", regression.New.Key(), want, regression.Match)
+		}
+	}
+}
+
+func TestBaselineFingerprintSurvivesRename(t *testing.T) {
+	funcs, err := analyser.Analyse("test_data/time_samples.go", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := baseline.New("test_data", funcs)
+	renamed := baseline.Baseline{}
+	for _, entry := range current.Functions {
+		entry.Name = entry.Name + "Renamed"
+		entry.Time++
+		renamed.Functions = append(renamed.Functions, entry)
+	}
+
+	for _, regression := range baseline.Diff(current, renamed) {
+		if regression.Match != baseline.MatchRenamed {
+			t.Errorf("%s: expected renamed match, got %s", regression.New.Key(), regression.Match)
+		}
+	}
+	if got := len(baseline.Diff(current, renamed)); got != len(current.Functions) {
+		t.Errorf("expected %d regressions, got %d", len(current.Functions), got)
+	}
+}
@@ -0,0 +1,135 @@
+package test
+
+import (
+	"context"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"github.com/DanyloPiatyhorets/funalyser/cache"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	src, err := os.ReadFile("test_data/call_samples.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "call_samples.go")
+	dir := filepath.Join(t.TempDir(), "cache")
+	run := func(src string) (*analyser.Report, cache.Stats) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		analysisCache, err := cache.Open(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		report, err := analyser.Run(context.Background(), []string{file}, analyser.Options{Cache: analysisCache})
+		if err != nil {
+			t.Fatal(err)
+		}
+		before, _ := cache.ReadStats(dir)
+		if err := analysisCache.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		after, _ := cache.ReadStats(dir)
+		return report, cache.Stats{Hits: after.Hits - before.Hits, Misses: after.Misses - before.Misses}
+	}
+
+	uncached, err := analyser.Run(context.Background(), []string{"test_data/call_samples.go"}, analyser.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, stats := run(string(src))
+	if stats.Hits != 0 || stats.Misses != 4 {
+		t.Errorf("expected 4 misses on a cold cache, got %+v", stats)
+	}
+	second, stats := run(string(src))
+	if stats.Hits != 4 || stats.Misses != 0 {
+		t.Errorf("expected 4 hits on a warm cache, got %+v", stats)
+	}
+	if !reflect.DeepEqual(first, second) || !sameComplexities(uncached, second) {
+		t.Error("expected cached results to be the results of an analysis")
+	}
+
+	// moving functions down keeps them cached at their new position
+	moved, stats := run(strings.Replace(string(src), "package main\n", "package main\n\n\n", 1))
+	if stats.Misses != 0 || moved.Functions[0].Line != first.Functions[0].Line+2 {
+		t.Errorf("expected moved functions to be hits at their new line, got %+v and line %d", stats, moved.Functions[0].Line)
+	}
+
+	// sumItems becomes constant, so sumInLoop is dirty too while isEven and isOdd are not
+	changed, stats := run(strings.Replace(string(src), "for _, item := range items {\n\t\ttotal += item\n\t}", "total += items[0]", 1))
+	if stats.Hits != 2 || stats.Misses != 2 {
+		t.Errorf("expected sumItems and sumInLoop only to be analysed again, got %+v", stats)
+	}
+	if changed.Functions[1].Complexity.Time != 1 {
+		t.Errorf("expected sumInLoop to be O(n) once sumItems is O(1), got %s", analyser.FormatComplexity(changed.Functions[1].Complexity.Time))
+	}
+
+	if err := cache.Clear(dir); err != nil {
+		t.Fatal(err)
+	}
+	if stats, _ := cache.ReadStats(dir); stats.Entries != 0 {
+		t.Errorf("expected an empty cache once cleared, got %+v", stats)
+	}
+}
+
+func TestCacheSiblingTypes(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+	build := filepath.Join(dir, "build.go")
+	label := filepath.Join(dir, "label.go")
+	if err := os.WriteFile(build, []byte("package labels\n\nfunc build(labels []Label) Label {\n\tvar out Label\n\tfor _, l := range labels {\n\t\tout += l\n\t}\n\treturn out\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run := func(labelSrc string) (*analyser.Report, cache.Stats) {
+		t.Helper()
+		if err := os.WriteFile(label, []byte(labelSrc), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		analysisCache, err := cache.Open(cacheDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		report, err := analyser.Run(context.Background(), []string{build, label}, analyser.Options{Cache: analysisCache, Functions: []string{"build"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		before, _ := cache.ReadStats(cacheDir)
+		if err := analysisCache.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		after, _ := cache.ReadStats(cacheDir)
+		return report, cache.Stats{Hits: after.Hits - before.Hits, Misses: after.Misses - before.Misses}
+	}
+	concatenates := func(report *analyser.Report) bool {
+		return slices.ContainsFunc(report.Diagnostics, func(diagnostic analyser.Diagnostic) bool {
+			return diagnostic.Rule == analyser.RuleStringConcat
+		})
+	}
+
+	report, _ := run("package labels\n\ntype Label string\n\nfunc name() string { return \"a\" }\n")
+	if !concatenates(report) {
+		t.Fatal("expected labels of type string to be reported as concatenated in a loop")
+	}
+	// a body in the sibling file does not change the types build checks with
+	if _, stats := run("package labels\n\ntype Label string\n\nfunc name() string { return \"b\" }\n"); stats.Hits != 1 || stats.Misses != 0 {
+		t.Errorf("expected build to stay cached when a sibling body changes, got %+v", stats)
+	}
+	report, stats := run("package labels\n\ntype Label int\n\nfunc name() string { return \"b\" }\n")
+	if stats.Hits != 0 || stats.Misses != 1 {
+		t.Errorf("expected build to be analysed again once Label changed in the sibling file, got %+v", stats)
+	}
+	if concatenates(report) {
+		t.Error("expected labels of type int not to be reported as concatenated, got the cached result")
+	}
+}
+
+func sameComplexities(expected *analyser.Report, got *analyser.Report) bool {
+	return reflect.DeepEqual(complexities(expected.Functions), complexities(got.Functions))
+}
@@ -0,0 +1,43 @@
+package test
+
+import (
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"testing"
+)
+
+func TestStringConcat(t *testing.T) {
+	funcs, err := analyser.Analyse("test_data/concat_samples.go", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]struct {
+		time float32
+		line int
+	}{
+		"joinWords":   {2, 8},
+		"joinLines":   {2, 16},
+		"sumNumbers":  {1, 0},
+		"fixedConcat": {0, 0},
+		"builderJoin": {1, 0},
+	}
+	if len(funcs) != len(expected) {
+		t.Errorf("expected %d functions, got %d", len(expected), len(funcs))
+	}
+	for _, fn := range funcs {
+		want, ok := expected[fn.Name]
+		if !ok {
+			t.Errorf("No expected result for %s", fn.Name)
+			continue
+		}
+		if fn.Complexity.Time != want.time {
+			t.Errorf("time for %s: expected %f, got %f", fn.Name, want.time, fn.Complexity.Time)
+		}
+		reported := hasDiagnostic(fn.Diagnostics, fn.Name, analyser.RuleStringConcat)
+		if reported != (want.line != 0) {
+			t.Errorf("expected %s to be reported: %v, got %+v", fn.Name, want.line != 0, fn.Diagnostics)
+		} else if reported && fn.Diagnostics[0].Line != want.line {
+			t.Errorf("expected %s to be reported at line %d, got %+v", fn.Name, want.line, fn.Diagnostics)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package test
+
+import (
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"testing"
+)
+
+func TestConfidence(t *testing.T) {
+	funcs, err := analyser.Analyse("test_data/confidence_samples.go", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]struct {
+		confidence analyser.Confidence
+		kind       string
+		line       int
+		complexity analyser.Complexity
+	}{
+		"sumSlice":        {analyser.ConfidenceHigh, "", 0, analyser.Complexity{Time: 1}},
+		"findAll":         {analyser.ConfidenceMedium, analyser.UnresolvedCall, 18, analyser.Complexity{Time: 1}},
+		"drainQueue":      {analyser.ConfidenceLow, analyser.UnresolvedLoop, 25, analyser.Complexity{}},
+		"countedLoop":     {analyser.ConfidenceLow, analyser.UnresolvedLoop, 35, analyser.Complexity{}},
+		"copyItems":       {analyser.ConfidenceLow, analyser.UnresolvedSize, 42, analyser.Complexity{}},
+		"sizeOfAny":       {analyser.ConfidenceHigh, "", 0, analyser.Complexity{Time: 1}},
+		"sumOrStop":       {analyser.ConfidenceHigh, "", 0, analyser.Complexity{Time: 1}},
+		"sumInBackground": {analyser.ConfidenceHigh, "", 0, analyser.Complexity{Time: 1}},
+		"sumOnReturn":     {analyser.ConfidenceHigh, "", 0, analyser.Complexity{Time: 1}},
+		"zeroes":          {analyser.ConfidenceHigh, "", 0, analyser.Complexity{Space: 1}},
+		"reversedCopy":    {analyser.ConfidenceHigh, "", 0, analyser.Complexity{Time: 1, Space: 1}},
+		"reversedLength":  {analyser.ConfidenceHigh, "", 0, analyser.Complexity{Time: 1, Space: 1}},
+	}
+	if len(funcs) != len(expected) {
+		t.Errorf("expected %d functions, got %d", len(expected), len(funcs))
+	}
+	for _, fn := range funcs {
+		want, ok := expected[fn.Name]
+		if !ok {
+			t.Errorf("No expected result for %s", fn.Name)
+			continue
+		}
+		// constructs like type switches, selects, closures and calls in arguments are visited,
+		// a loop inside them is never a silent O(1)
+		if fn.Complexity != want.complexity {
+			t.Errorf("complexity of %s: expected %+v, got %+v", fn.Name, want.complexity, fn.Complexity)
+		}
+		if fn.Confidence != want.confidence {
+			t.Errorf("confidence of %s: expected %s, got %s %+v", fn.Name, want.confidence, fn.Confidence, fn.Unresolved)
+			continue
+		}
+		if want.kind == "" {
+			if len(fn.Unresolved) != 0 {
+				t.Errorf("expected nothing unresolved in %s, got %+v", fn.Name, fn.Unresolved)
+			}
+		} else if len(fn.Unresolved) != 1 || fn.Unresolved[0].Kind != want.kind || fn.Unresolved[0].Line != want.line {
+			t.Errorf("expected an unresolved %s at line %d in %s, got %+v", want.kind, want.line, fn.Name, fn.Unresolved)
+		}
+	}
+
+	if got := analyser.FormatLowerBound(1, []analyser.Unresolved{{Line: 15}, {Line: 12}, {Line: 15}}); got != "≥ O(n), unknown contributions at lines 12, 15" {
+		t.Errorf("unexpected lower bound %q", got)
+	}
+	if got := analyser.FormatLowerBound(1, nil); got != "O(n)" {
+		t.Errorf("unexpected complexity %q", got)
+	}
+}
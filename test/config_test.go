@@ -0,0 +1,67 @@
+package test
+
+import (
+	"github.com/DanyloPiatyhorets/funalyser/config"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigDiscovery(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "internal", "legacy")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	configFile := `
+exclude: ["**/*_gen.go"]
+thresholds: {time: O(n^2), space: O(n)}
+overrides:
+  - path: internal/legacy
+    tests: include
+    thresholds: {time: O(n^3)}
+`
+	if err := os.WriteFile(filepath.Join(root, ".funalyser.yaml"), []byte(configFile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	projectConfig, err := config.Discover(nested)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if projectConfig.Dir != root {
+		t.Fatalf("expected the config of %s, got %s", root, projectConfig.Dir)
+	}
+
+	settings := projectConfig.For(filepath.Join(root, "main.go"))
+	if *settings.Thresholds.Time != 2 || *settings.Thresholds.Space != 1 {
+		t.Errorf("unexpected root thresholds %v %v", *settings.Thresholds.Time, *settings.Thresholds.Space)
+	}
+	if settings.Includes("main_test.go") || settings.Includes("api/types_gen.go") || !settings.Includes("api/types.go") {
+		t.Errorf("unexpected file selection with %+v", settings)
+	}
+
+	legacy := projectConfig.For(filepath.Join(nested, "old.go"))
+	if *legacy.Thresholds.Time != 3 || *legacy.Thresholds.Space != 1 {
+		t.Errorf("unexpected legacy thresholds %v %v", *legacy.Thresholds.Time, *legacy.Thresholds.Space)
+	}
+	if !legacy.Includes("internal/legacy/old_test.go") {
+		t.Error("expected the legacy override to include tests")
+	}
+}
+
+func TestConfigGlobs(t *testing.T) {
+	expected := map[[2]string]bool{
+		{"**/*.go", "main.go"}:                true,
+		{"**/*.go", "cmd/root.go"}:            true,
+		{"*.go", "cmd/root.go"}:               false,
+		{"cmd/**", "cmd/sub/root.go"}:         true,
+		{"**/testdata/**", "a/testdata/x.go"}: true,
+		{"api/?.go", "api/ab.go"}:             false,
+	}
+	for pattern, want := range expected {
+		if got := config.Match(pattern[0], pattern[1]); got != want {
+			t.Errorf("Match(%q, %q): expected %v, got %v", pattern[0], pattern[1], want, got)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package test
+
+import (
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"testing"
+)
+
+func TestDirectives(t *testing.T) {
+	file := "test_data/directive_samples.go"
+	funcs, err := analyser.Analyse(file, "")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedTime := map[string]float32{
+		"matchesExpectation":     1,
+		"contradictsExpectation": 2,
+		"ignoredExpectation":     1,
+		"assumedBound":           1,
+		"assumedLength":          0,
+		"malformedDirectives":    0,
+	}
+	expectedDiagnostics := map[string][]string{
+		"contradictsExpectation": {analyser.RuleExpect},
+		"malformedDirectives":    {analyser.RuleDirective, analyser.RuleDirective},
+	}
+
+	for _, fn := range funcs {
+		want, ok := expectedTime[fn.Name]
+		if !ok {
+			t.Errorf("No expected result for %s", fn.Name)
+		} else if fn.Complexity.Time != want {
+			t.Errorf("time for %s: expected %f, got %f", fn.Name, want, fn.Complexity.Time)
+		}
+
+		var rules []string
+		for _, diagnostic := range fn.Diagnostics {
+			rules = append(rules, diagnostic.Rule)
+		}
+		if len(rules) != len(expectedDiagnostics[fn.Name]) {
+			t.Errorf("diagnostics for %s: expected %v, got %v", fn.Name, expectedDiagnostics[fn.Name], fn.Diagnostics)
+			continue
+		}
+		for i, rule := range rules {
+			if rule != expectedDiagnostics[fn.Name][i] {
+				t.Errorf("diagnostic %d for %s: expected %s, got %s", i, fn.Name, expectedDiagnostics[fn.Name][i], rule)
+			}
+		}
+	}
+}
+
+func TestParseComplexity(t *testing.T) {
+	expected := map[string]float32{
+		"O(1)":       0,
+		"O(log n)":   0.5,
+		"n":          1,
+		"O(n log n)": 1.5,
+		"O(n*log n)": 1.5,
+		"O(n²)":      2,
+		"O(n^3)":     3,
+		"n^2 log n":  2.5,
+	}
+	for text, want := range expected {
+		got, err := analyser.ParseComplexity(text)
+		if err != nil {
+			t.Errorf("%s: %v", text, err)
+		} else if got != want {
+			t.Errorf("%s: expected %f, got %f", text, want, got)
+		}
+		if _, err := analyser.ParseComplexity(analyser.FormatComplexity(want)); err != nil {
+			t.Errorf("cannot read back %s: %v", analyser.FormatComplexity(want), err)
+		}
+	}
+	if _, err := analyser.ParseComplexity("O(2^n)"); err == nil {
+		t.Error("expected an error for O(2^n)")
+	}
+}
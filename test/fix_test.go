@@ -0,0 +1,191 @@
+package test
+
+import (
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPreallocFixes(t *testing.T) {
+	funcs, err := analyser.Analyse("test_data/fix_samples.go", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]struct {
+		reported bool
+		fixes    int
+	}{
+		"squaresUpTo":       {true, 1},
+		"userNames":         {true, 0},
+		"doubledAfterReset": {false, 0},
+		"Bump":              {false, 0},
+		"encodeVersions":    {true, 0},
+	}
+	if len(funcs) != len(expected) {
+		t.Errorf("expected %d functions, got %d", len(expected), len(funcs))
+	}
+	for _, fn := range funcs {
+		want, ok := expected[fn.Name]
+		if !ok {
+			t.Errorf("No expected result for %s", fn.Name)
+			continue
+		}
+		var diagnostics []analyser.Diagnostic
+		for _, diagnostic := range fn.Diagnostics {
+			if diagnostic.Rule == analyser.RulePrealloc {
+				diagnostics = append(diagnostics, diagnostic)
+			}
+		}
+		if !want.reported {
+			if len(diagnostics) != 0 {
+				t.Errorf("expected no prealloc diagnostic for %s, got %+v", fn.Name, diagnostics)
+			}
+			continue
+		}
+		if len(diagnostics) != 1 || len(diagnostics[0].Fixes) != want.fixes {
+			t.Errorf("expected a prealloc diagnostic with %d fixes for %s, got %+v", want.fixes, fn.Name, diagnostics)
+		}
+	}
+}
+
+func TestApplyFixes(t *testing.T) {
+	tests := []struct {
+		file     string
+		contains []string
+		absent   []string
+	}{
+		{"test_data/fix_samples.go", []string{"out := make([]int, 0, n)", "var result []string", "current.Bump()\n\t\tdata, _ := json.Marshal(current)"}, []string{"out := []int{}"}},
+		{"test_data/membership_samples.go", []string{"bsSet := make(map[int]bool, len(bs))", "if bsSet[a] {"}, nil},
+		{"test_data/invariant_samples.go", []string{"count := 0\n\tpattern := regexp.MustCompile(`^\\d+$`)\n\tfor _, line := range lines {", "var encoded [][]byte\n\tdata, _ := json.Marshal(cfg)\n\tfor i := 0; i < times; i++ {"}, nil},
+		{"test_data/fixable_samples.go", []string{"names := []string{}", "bsSet2 := make(map[int]bool, len(bs))", "if bsSet2[a] {"}, []string{`import "slices"`}},
+	}
+	for _, tt := range tests {
+		funcs, err := analyser.Analyse(tt.file, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fixes []analyser.SuggestedFix
+		for _, fn := range funcs {
+			for _, diagnostic := range fn.Diagnostics {
+				fixes = append(fixes, diagnostic.Fixes...)
+			}
+		}
+		src, err := os.ReadFile(tt.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fixed, applied, err := analyser.ApplyFixes(src, fixes)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.file, err)
+		}
+		if applied != len(fixes) {
+			t.Errorf("%s: expected %d fixes applied, got %d", tt.file, len(fixes), applied)
+		}
+		for _, text := range tt.contains {
+			if !strings.Contains(string(fixed), text) {
+				t.Errorf("%s: expected the fixed source to contain %q, got\n%s", tt.file, text, fixed)
+			}
+		}
+		for _, text := range tt.absent {
+			if strings.Contains(string(fixed), text) {
+				t.Errorf("%s: expected the fixed source not to contain %q", tt.file, text)
+			}
+		}
+	}
+}
+
+func TestApplyOverlappingFixes(t *testing.T) {
+	src := []byte("package main\n\nvar x = 1\n")
+	fixes := []analyser.SuggestedFix{
+		{Message: "first", TextEdits: []analyser.TextEdit{{Line: 3, Column: 9, EndLine: 3, EndColumn: 10, NewText: "2"}}},
+		{Message: "second", TextEdits: []analyser.TextEdit{{Line: 3, Column: 9, EndLine: 3, EndColumn: 10, NewText: "3"}}},
+	}
+	fixed, applied, err := analyser.ApplyFixes(src, fixes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != 1 || string(fixed) != "package main\n\nvar x = 2\n" {
+		t.Errorf("expected only the first fix applied, got %d fixes and\n%s", applied, fixed)
+	}
+}
+
+func TestHoistFixes(t *testing.T) {
+	funcs, err := analyser.Analyse("test_data/fix_samples.go", "encodeVersions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, diagnostic := range funcs[0].Diagnostics {
+		if diagnostic.Rule == analyser.RuleLoopInvariant && len(diagnostic.Fixes) != 0 {
+			t.Errorf("expected no fix moving json.Marshal of a value the loop changes through a method, got %+v", diagnostic.Fixes)
+		}
+	}
+}
+
+func TestFixesTypeCheck(t *testing.T) {
+	files, err := filepath.Glob("test_data/*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		funcs, err := analyser.Analyse(file, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fixes []analyser.SuggestedFix
+		for _, fn := range funcs {
+			for _, diagnostic := range fn.Diagnostics {
+				fixes = append(fixes, diagnostic.Fixes...)
+			}
+		}
+		if len(fixes) == 0 {
+			continue
+		}
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// every fix on its own, then all of them together
+		sets := [][]analyser.SuggestedFix{fixes}
+		for _, fix := range fixes {
+			sets = append(sets, []analyser.SuggestedFix{fix})
+		}
+		for _, set := range sets {
+			fixed, _, err := analyser.ApplyFixes(src, set)
+			if err == nil {
+				err = analyser.CheckFixes(file, src, fixed)
+			}
+			if err != nil {
+				t.Errorf("%s: %s: %v", file, set[0].Message, err)
+			}
+		}
+	}
+
+	// editors apply the edits as they are, so the fix removes the import it leaves unused itself
+	funcs, err := analyser.Analyse("test_data/fixable_samples.go", "sharedIDs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	removesImport := false
+	for _, diagnostic := range funcs[0].Diagnostics {
+		for _, fix := range diagnostic.Fixes {
+			for _, textEdit := range fix.TextEdits {
+				removesImport = removesImport || textEdit.Line == 3 && textEdit.EndLine == 4 && textEdit.NewText == ""
+			}
+		}
+	}
+	if !removesImport {
+		t.Errorf("expected the set lookup fix to remove the slices import, got %+v", funcs[0].Diagnostics)
+	}
+
+	src, err := os.ReadFile("test_data/fixable_samples.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	broken := strings.Replace(string(src), "names := []string{}", "names := make([]string, 0, len(loaded))", 1)
+	if err := analyser.CheckFixes("test_data/fixable_samples.go", src, []byte(broken)); err == nil || !strings.Contains(err.Error(), "undefined: loaded") {
+		t.Errorf("expected a fix reading loaded before it is declared to be refused, got %v", err)
+	}
+}
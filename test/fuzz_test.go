@@ -0,0 +1,74 @@
+package test
+
+import (
+	"context"
+	"errors"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var fuzzSeeds = []string{
+	"package main\n\n//go:linkname now runtime.nanotime\nfunc now() int64\n",
+	"package main\n\nfunc spin() {\n\tspin()\n}\n",
+	"package main\n\nfunc grow(n int) []int {\n\treturn make([]int)\n}\n",
+	"package main\n\nfunc broken(n int) int {\n\tx := 1 2\n\treturn x\n}\n\nfunc fine(items []int) {\n\tfor _, item := range items {\n\t\tprintln(item)\n\t}\n}\n",
+	"package main\n\nfunc (s *stack[T]) Push(v T) { s.items = append(s.items, v) }\n",
+	"package",
+	"",
+}
+
+func FuzzAnalyse(f *testing.F) {
+	samples, err := filepath.Glob("test_data/*.go")
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, sample := range samples {
+		src, err := os.ReadFile(sample)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(src)
+	}
+	for _, seed := range fuzzSeeds {
+		f.Add([]byte(seed))
+	}
+
+	options := analyser.Options{Analysers: analyser.Analysers()}
+	f.Fuzz(func(t *testing.T, src []byte) {
+		if _, err := analyser.RunSource(context.Background(), "fuzz.go", src, options); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestPartialResults(t *testing.T) {
+	report, err := analyser.RunSource(context.Background(), "partial.go", []byte(fuzzSeeds[3]), analyser.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Functions) != 1 || report.Functions[0].Name != "fine" || report.Functions[0].Complexity.Time != 1 {
+		t.Errorf("expected only fine to be analysed, got %+v", report.Functions)
+	}
+	if len(report.Diagnostics) == 0 || report.Diagnostics[0].Rule != analyser.RuleSyntax || report.Diagnostics[0].Line != 4 {
+		t.Errorf("expected a syntax error on line 4, got %+v", report.Diagnostics)
+	}
+
+	funcs, err := analyser.AnalyseSource("partial.go", []byte(fuzzSeeds[3]), "")
+	var syntaxError *analyser.SyntaxError
+	if !errors.As(err, &syntaxError) || syntaxError.Diagnostics[0].Line != 4 {
+		t.Errorf("expected a syntax error on line 4, got %v", err)
+	}
+	if len(funcs) != 1 || funcs[0].Name != "fine" {
+		t.Errorf("expected fine to be analysed despite the syntax error, got %+v", funcs)
+	}
+
+	report, err = analyser.RunSource(context.Background(), "linkname.go", []byte(fuzzSeeds[0]), analyser.Options{})
+	if err != nil || len(report.Functions) != 0 {
+		t.Errorf("expected functions without a body to be skipped, got %+v, %v", report, err)
+	}
+	if len(report.Diagnostics) != 1 || report.Diagnostics[0].Rule != analyser.RuleNoBody || report.Diagnostics[0].Line != 4 {
+		t.Errorf("expected a diagnostic on the function without a body, got %+v", report.Diagnostics)
+	}
+}
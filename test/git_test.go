@@ -0,0 +1,144 @@
+package test
+
+import (
+	"encoding/json"
+	"github.com/DanyloPiatyhorets/funalyser/git"
+	"github.com/DanyloPiatyhorets/funalyser/report"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestChangedLines(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	runGit := gitRunner(t, dir)
+	writeFile := func(content string) {
+		writeRepoFile(t, dir, "main.go", content)
+	}
+
+	writeFile("package main\n\nfunc a() {}\n\nfunc b() {}\n\nfunc c() {\n\tprintln()\n}\n")
+	runGit("init", "-q")
+	runGit("add", ".")
+	runGit("commit", "-q", "-m", "initial")
+	writeFile("package main\n\nfunc a() { println() }\n\nfunc b() {}\n\nfunc c() {\n}\n")
+
+	workingDir, _ := os.Getwd()
+	defer os.Chdir(workingDir)
+	os.Chdir(dir)
+
+	changed, err := git.ChangedLines("HEAD", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []git.LineRange{{Start: 3, End: 3}, {Start: 7, End: 8}}
+	got := changed["main.go"]
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("hunk %d: expected %v, got %v", i, expected[i], got[i])
+		}
+	}
+
+	old, err := git.Show("HEAD", "main.go")
+	if err != nil || len(old) == 0 {
+		t.Errorf("expected the committed content of main.go, got %q (%v)", old, err)
+	}
+	if added, err := git.Show("HEAD", "added.go"); err != nil || added != nil {
+		t.Errorf("expected a file missing at the revision to be new, got %q (%v)", added, err)
+	}
+	if _, err := git.Show("no-such-branch", "main.go"); err == nil {
+		t.Error("expected an unknown revision to be an error, not a new file")
+	}
+}
+
+func TestSince(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	binary := filepath.Join(t.TempDir(), "funalyser")
+	if output, err := exec.Command("go", "build", "-o", binary, "..").CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, output)
+	}
+	dir := t.TempDir()
+	runGit := gitRunner(t, dir)
+
+	// process calls count from another file, only its body changes
+	writeRepoFile(t, dir, "process.go", "package main\n\nfunc process(items []int) int {\n\treturn count(items)\n}\n")
+	writeRepoFile(t, dir, "count.go", "package main\n\nfunc count(items []int) int {\n\tn := 0\n\tfor range items {\n\t\tn++\n\t}\n\treturn n\n}\n\nfunc idle() {}\n")
+	writeRepoFile(t, dir, "summary.go", "package main\n\nfunc summary(items []int) int {\n\treturn process(items) + 1\n}\n")
+	runGit("init", "-q")
+	runGit("add", ".")
+	runGit("commit", "-q", "-m", "initial")
+	writeRepoFile(t, dir, "process.go", "package main\n\nfunc process(items []int) int {\n\ttotal := count(items)\n\treturn total\n}\n\nfunc added(items []int) int {\n\treturn len(items)\n}\n")
+
+	command := exec.Command(binary, "analyse", ".", "--since", "HEAD", "--json")
+	command.Dir = dir
+	output, err := command.Output()
+	if err != nil {
+		t.Fatalf("analyse --since: %v\n%s", err, output)
+	}
+	var changes report.Changes
+	if err := json.Unmarshal(output, &changes); err != nil {
+		t.Fatalf("%v\n%s", err, output)
+	}
+
+	type change struct {
+		reason string
+		// before is empty for a new function
+		before string
+		after  string
+	}
+	expected := map[string]change{
+		"process": {reason: "changed", before: "O(n)", after: "O(n)"},
+		"added":   {reason: "changed", after: "O(1)"},
+		"summary": {reason: "calls main.process", before: "O(n)", after: "O(n)"},
+	}
+	if len(changes.Changes) != len(expected) {
+		t.Errorf("expected %d changed functions, got %s", len(expected), output)
+	}
+	for _, got := range changes.Changes {
+		want, ok := expected[got.Function.Name]
+		if !ok {
+			t.Errorf("No expected result for %s", got.Function.Name)
+			continue
+		}
+		if got.Reason != want.reason {
+			t.Errorf("%s: expected reason %q, got %q", got.Function.Name, want.reason, got.Reason)
+		}
+		if got.Function.Time.Class != want.after {
+			t.Errorf("%s: expected %s now, got %s", got.Function.Name, want.after, got.Function.Time.Class)
+		}
+		switch {
+		case want.before == "" && got.Before != nil:
+			t.Errorf("%s: expected a new function, got %s before", got.Function.Name, got.Before.Time.Class)
+		case want.before != "" && got.Before == nil:
+			t.Errorf("%s: expected %s before, got a new function", got.Function.Name, want.before)
+		case want.before != "" && got.Before.Time.Class != want.before:
+			// the callee in another file must cost the same at the revision
+			t.Errorf("%s: expected %s before, got %s", got.Function.Name, want.before, got.Before.Time.Class)
+		}
+	}
+}
+
+// gitRunner runs git commands in dir with a test identity
+func gitRunner(t *testing.T, dir string) func(args ...string) {
+	return func(args ...string) {
+		command := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		command.Dir = dir
+		if output, err := command.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+}
+
+func writeRepoFile(t *testing.T, dir string, name string, content string) {
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,16 @@
+package test
+
+import (
+	"github.com/DanyloPiatyhorets/funalyser/analyser/go/goanalysis"
+	"golang.org/x/tools/go/analysis/analysistest"
+	"testing"
+)
+
+func TestAnalyzer(t *testing.T) {
+	if err := goanalysis.Analyzer.Flags.Set("max-time", "O(n)"); err != nil {
+		t.Fatal(err)
+	}
+	defer goanalysis.Analyzer.Flags.Set("max-time", "")
+
+	analysistest.Run(t, analysistest.TestData(), goanalysis.Analyzer, "sorting", "caller")
+}
@@ -0,0 +1,60 @@
+package test
+
+import (
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"testing"
+)
+
+func TestLoopSetup(t *testing.T) {
+	funcs, err := analyser.Analyse("test_data/invariant_samples.go", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]struct {
+		rule  string
+		line  int
+		space float32
+	}{
+		"matchLines":        {analyser.RuleLoopInvariant, 13, 0},
+		"matchLinesHoisted": {"", 0, 0},
+		"encodeEach":        {"", 0, 1},
+		"closeAll":          {analyser.RuleDeferInLoop, 47, 1},
+		"Increment":         {"", 0, 0},
+		"encodeCounts":      {"", 0, 1},
+		"localTimes":        {analyser.RuleLoopInvariant, 70, 1},
+		// arguments that are variables the loop does not change
+		"matchPattern":   {analyser.RuleLoopInvariant, 79, 0},
+		"encodeSettings": {analyser.RuleLoopInvariant, 91, 1},
+		"rename":         {"", 0, 0},
+		// cfg changes through the pointer rename is given, zoneName is a package variable
+		"encodeRenamed": {"", 0, 1},
+		"localTimesIn":  {"", 0, 1},
+	}
+	if len(funcs) != len(expected) {
+		t.Errorf("expected %d functions, got %d", len(expected), len(funcs))
+	}
+	for _, fn := range funcs {
+		want, ok := expected[fn.Name]
+		if !ok {
+			t.Errorf("No expected result for %s", fn.Name)
+			continue
+		}
+		var diagnostics []analyser.Diagnostic
+		for _, diagnostic := range fn.Diagnostics {
+			if diagnostic.Rule == analyser.RuleLoopInvariant || diagnostic.Rule == analyser.RuleDeferInLoop {
+				diagnostics = append(diagnostics, diagnostic)
+			}
+		}
+		if want.rule == "" {
+			if len(diagnostics) != 0 {
+				t.Errorf("expected no diagnostics for %s, got %+v", fn.Name, diagnostics)
+			}
+		} else if len(diagnostics) != 1 || diagnostics[0].Rule != want.rule || diagnostics[0].Line != want.line {
+			t.Errorf("expected %s at line %d for %s, got %+v", want.rule, want.line, fn.Name, diagnostics)
+		}
+		if fn.Complexity.Space != want.space {
+			t.Errorf("space for %s: expected %f, got %f", fn.Name, want.space, fn.Complexity.Space)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/DanyloPiatyhorets/funalyser/lsp"
+	"strings"
+	"testing"
+)
+
+func TestLanguageServer(t *testing.T) {
+	uri := "file:///nowhere/on/disk/main.go"
+	source := "package main\n\nfunc sum(items []int) int {\n\ttotal := 0\n\tfor _, item := range items {\n\t\ttotal += item\n\t}\n\treturn total\n}\n"
+	changed := strings.Replace(source, "\t\ttotal += item\n", "\t\tfor range items {\n\t\t\ttotal += item\n\t\t}\n", 1)
+	// a function being typed above sum moves it down by 5 lines
+	broken := strings.Replace(changed, "package main\n", "package main\n\nfunc typing(n int) int {\n\tx := 1 2\n\treturn x\n}\n", 1)
+
+	var input bytes.Buffer
+	send := func(id int, method string, params any) {
+		request := map[string]any{"jsonrpc": "2.0", "method": method, "params": params}
+		if id != 0 {
+			request["id"] = id
+		}
+		body, _ := json.Marshal(request)
+		fmt.Fprintf(&input, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+	document := map[string]any{"uri": uri}
+	send(1, "initialize", map[string]any{})
+	send(0, "initialized", map[string]any{})
+	send(0, "textDocument/didOpen", map[string]any{"textDocument": map[string]any{"uri": uri, "text": source}})
+	send(2, "textDocument/codeLens", map[string]any{"textDocument": document})
+	send(0, "textDocument/didChange", map[string]any{"textDocument": document, "contentChanges": []any{map[string]any{"text": changed}}})
+	send(3, "textDocument/inlayHint", map[string]any{"textDocument": document})
+	send(0, "textDocument/didChange", map[string]any{"textDocument": document, "contentChanges": []any{map[string]any{"text": broken}}})
+	send(5, "textDocument/inlayHint", map[string]any{"textDocument": document})
+	send(4, "shutdown", nil)
+	send(0, "exit", nil)
+
+	var output bytes.Buffer
+	if err := lsp.NewServer(&input, &output).Serve(); err != nil {
+		t.Fatal(err)
+	}
+
+	responses := map[float64]json.RawMessage{}
+	notifications := 0
+	var published json.RawMessage
+	for _, frame := range strings.Split(output.String(), "Content-Length: ")[1:] {
+		_, body, _ := strings.Cut(frame, "\r\n\r\n")
+		var response struct {
+			ID     *float64        `json:"id"`
+			Method string          `json:"method"`
+			Result json.RawMessage `json:"result"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal([]byte(body), &response); err != nil {
+			t.Fatalf("invalid frame %q: %v", body, err)
+		}
+		if response.ID != nil {
+			responses[*response.ID] = response.Result
+		} else if response.Method == "textDocument/publishDiagnostics" {
+			notifications++
+			published = response.Params
+		}
+	}
+
+	if !strings.Contains(string(responses[2]), `"title":"O(n) time · O(1) space"`) || !strings.Contains(string(responses[2]), `"line":2`) {
+		t.Errorf("unexpected code lenses %s", responses[2])
+	}
+	if !strings.Contains(string(responses[3]), `"label":"O(n^2) time · O(1) space"`) {
+		t.Errorf("expected inlay hints from the changed buffer, got %s", responses[3])
+	}
+	if notifications != 3 {
+		t.Errorf("expected diagnostics to be published on open and every change, got %d", notifications)
+	}
+	if !strings.Contains(string(published), `"line":3`) || !strings.Contains(string(published), `"severity":1,"code":"syntax"`) {
+		t.Errorf("expected the syntax error to be published on its line, got %s", published)
+	}
+	// mid-edit, the hints are those of the functions that still parse, where they are now
+	if !strings.Contains(string(responses[5]), `"position":{"line":7,`) || !strings.Contains(string(responses[5]), `"label":"O(n^2) time · O(1) space"`) || strings.Count(string(responses[5]), `"label"`) != 1 {
+		t.Errorf("expected a single inlay hint for sum at its current line mid-edit, got %s", responses[5])
+	}
+}
@@ -0,0 +1,44 @@
+package test
+
+import (
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"strings"
+	"testing"
+)
+
+func TestLinearSearch(t *testing.T) {
+	funcs, err := analyser.Analyse("test_data/membership_samples.go", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]struct {
+		time    float32
+		line    int
+		message string
+	}{
+		"commonCalls":  {2, 8, "bs is searched linearly on every iteration of the loop at line 7, O(len(as) · len(bs)) in total"},
+		"commonLoop":   {2, 18, "bs is searched linearly on every iteration of the loop at line 17, O(len(as) · len(bs)) in total"},
+		"allPairs":     {2, 0, ""},
+		"containsOnce": {1, 0, ""},
+	}
+	if len(funcs) != len(expected) {
+		t.Errorf("expected %d functions, got %d", len(expected), len(funcs))
+	}
+	for _, fn := range funcs {
+		want, ok := expected[fn.Name]
+		if !ok {
+			t.Errorf("No expected result for %s", fn.Name)
+			continue
+		}
+		if fn.Complexity.Time != want.time {
+			t.Errorf("time for %s: expected %f, got %f", fn.Name, want.time, fn.Complexity.Time)
+		}
+		reported := hasDiagnostic(fn.Diagnostics, fn.Name, analyser.RuleLinearSearch)
+		if reported != (want.line != 0) {
+			t.Errorf("expected %s to be reported: %v, got %+v", fn.Name, want.line != 0, fn.Diagnostics)
+		} else if reported && (fn.Diagnostics[0].Line != want.line || !strings.HasPrefix(fn.Diagnostics[0].Message, want.message)) {
+			t.Errorf("expected %s to be reported at line %d with %q, got %+v", fn.Name, want.line, want.message, fn.Diagnostics)
+		}
+	}
+}
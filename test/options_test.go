@@ -0,0 +1,242 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunOptions(t *testing.T) {
+	files := []string{"test_data/directive_samples.go", "test_data/time_samples.go"}
+	linear := float32(1)
+
+	report, err := analyser.Run(context.Background(), files, analyser.Options{
+		Rules:      []string{analyser.RuleThreshold},
+		Thresholds: analyser.Thresholds{Time: &linear},
+		Detail:     analyser.DetailSummary,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, diagnostic := range report.Diagnostics {
+		if diagnostic.Rule != analyser.RuleThreshold {
+			t.Errorf("expected only threshold diagnostics, got %+v", diagnostic)
+		}
+	}
+	if !hasDiagnostic(report.Diagnostics, "nestedLoop", analyser.RuleThreshold) {
+		t.Errorf("expected nestedLoop to exceed the threshold, got %+v", report.Diagnostics)
+	}
+	for _, fn := range report.Functions {
+		if fn.Calls != nil || fn.SymbolTable.Params != nil {
+			t.Errorf("expected %s to be summarised, got %+v", fn.Name, fn)
+		}
+	}
+
+	report, err = analyser.Run(context.Background(), files, analyser.Options{Functions: []string{"NESTEDLOOP", "assumedBound"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Functions) != 2 || report.Functions[0].Name != "assumedBound" || report.Functions[1].Name != "nestedLoop" {
+		t.Errorf("expected assumedBound and nestedLoop, got %+v", report.Functions)
+	}
+
+	report, err = analyser.Run(context.Background(), files, analyser.Options{Limits: analyser.Limits{MaxFunctions: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Functions) != 3 || !report.Truncated {
+		t.Errorf("expected a truncated report of 3 functions, got %d functions", len(report.Functions))
+	}
+
+	report, err = analyser.Run(context.Background(), files, analyser.Options{Limits: analyser.Limits{MaxFileSize: 10}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Functions) != 0 || len(report.Diagnostics) != 2 || report.Diagnostics[0].Rule != analyser.RuleLimit {
+		t.Errorf("expected both files to be skipped, got %+v", report)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := analyser.Run(ctx, []string{"test_data/time_samples.go"}, analyser.Options{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func hasDiagnostic(diagnostics []analyser.Diagnostic, function string, rule string) bool {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Function == function && diagnostic.Rule == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRunCallees(t *testing.T) {
+	files := []string{"test_data/call_samples.go", "test_data/time_samples.go", "test_data/sorting_samples.go"}
+	sequential, err := analyser.Run(context.Background(), files, analyser.Options{Jobs: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fn := range sequential.Functions {
+		if fn.Name == "sumInLoop" && fn.Complexity.Time != 2 {
+			t.Errorf("expected sumInLoop to account for the O(n) call in its loop, got %s", analyser.FormatComplexity(fn.Complexity.Time))
+		}
+	}
+
+	parallel, err := analyser.Run(context.Background(), files, analyser.Options{Jobs: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sequential, parallel) {
+		t.Error("expected the same report whatever the number of jobs")
+	}
+}
+
+func BenchmarkRun(b *testing.B) {
+	files := corpus(b, 100)
+	for _, jobs := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := analyser.Run(context.Background(), files, analyser.Options{Jobs: jobs}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// corpus copies the samples into packages of a temporary module
+func corpus(b *testing.B, packages int) []string {
+	samples, err := filepath.Glob("test_data/*.go")
+	if err != nil {
+		b.Fatal(err)
+	}
+	root := b.TempDir()
+	var files []string
+	for i := range packages {
+		dir := filepath.Join(root, fmt.Sprintf("package%d", i))
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			b.Fatal(err)
+		}
+		for _, sample := range samples {
+			src, err := os.ReadFile(sample)
+			if err != nil {
+				b.Fatal(err)
+			}
+			file := filepath.Join(dir, filepath.Base(sample))
+			if err := os.WriteFile(file, src, 0o644); err != nil {
+				b.Fatal(err)
+			}
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+func TestRunOnFunction(t *testing.T) {
+	files := []string{"test_data/call_samples.go", "test_data/time_samples.go"}
+	report, err := analyser.Run(context.Background(), files, analyser.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var streamed []analyser.FunctionInfo
+	streamedReport, err := analyser.Run(context.Background(), files, analyser.Options{
+		Jobs:       4,
+		OnFunction: func(fn analyser.FunctionInfo) { streamed = append(streamed, fn) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(streamedReport.Functions) != 0 {
+		t.Errorf("expected streamed functions to be left out of the report, got %d", len(streamedReport.Functions))
+	}
+	if len(streamed) != len(report.Functions) {
+		t.Fatalf("expected %d functions streamed, got %d", len(report.Functions), len(streamed))
+	}
+	// functions are streamed in the order of the report, however the workers finish
+	for i, fn := range report.Functions {
+		if !reflect.DeepEqual(streamed[i], fn) {
+			t.Errorf("streamed function %d is %s:%s, expected %s:%s as in the report", i, streamed[i].File, streamed[i].Name, fn.File, fn.Name)
+		}
+	}
+}
+
+func TestSelectsExactName(t *testing.T) {
+	src := []byte("package sums\n\nfunc Sum(items []int) int {\n\ttotal := 0\n\tfor _, item := range items {\n\t\ttotal += item\n\t}\n\treturn total\n}\n\nfunc sum(n int) int { return n }\n")
+	expected := map[string][]string{
+		"sum": {"sum"},
+		"Sum": {"Sum"},
+		"SUM": {"Sum", "sum"},
+	}
+	for name, want := range expected {
+		funcsInfo, err := analyser.AnalyseSource("sums.go", src, name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var got []string
+		for _, fn := range funcsInfo {
+			got = append(got, fn.Name)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("AnalyseSource %s: expected %v, got %v", name, want, got)
+		}
+	}
+
+	// a name only needs to match exactly in one file of the run
+	dir := t.TempDir()
+	other := filepath.Join(dir, "other.go")
+	if err := os.WriteFile(other, []byte("package sums\n\nfunc SUM() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sums := filepath.Join(dir, "sums.go")
+	if err := os.WriteFile(sums, src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	report, err := analyser.Run(context.Background(), []string{sums, other}, analyser.Options{Functions: []string{"SUM"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Functions) != 1 || report.Functions[0].File != other {
+		t.Errorf("Run SUM: expected only SUM of other.go, got %d functions", len(report.Functions))
+	}
+}
+
+func TestFunctionCase(t *testing.T) {
+	dir := t.TempDir()
+	src := "package sums\n\nfunc Sum(items []int) int {\n\ttotal := 0\n\tfor _, item := range items {\n\t\ttotal += item\n\t}\n\treturn total\n}\n\nfunc sum(n int) int { return n }\n"
+	if err := os.WriteFile(filepath.Join(dir, "sums.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]string{
+		"sum": {"sum"},
+		"Sum": {"Sum"},
+		"SUM": {"Sum", "sum"},
+	}
+	for name, want := range expected {
+		for _, path := range []string{dir, filepath.Join(dir, "sums.go")} {
+			output, err := exec.Command("go", "run", "..", "analyse", path, "--func", name, "--format", "csv").CombinedOutput()
+			if err != nil {
+				t.Fatalf("analyse %s --func %s: %v\n%s", path, name, err, output)
+			}
+			var got []string
+			for _, row := range strings.Split(strings.TrimSpace(string(output)), "\n")[1:] {
+				got = append(got, strings.Split(row, ",")[4])
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("analyse %s --func %s: expected %v, got %v", path, name, want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package test
+
+import (
+	"context"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+const registrySample = `package main
+
+func spawn(jobs []int, results chan int) {
+	for _, job := range jobs {
+		go func() {
+			results <- job
+		}()
+		buffer := make([]int, job)
+		_ = buffer
+	}
+	done := make(chan bool)
+	go func() { done <- true }()
+}
+`
+
+// goInLoop is a team-specific rule written outside the analyser package
+type goInLoop struct{}
+
+func (goInLoop) Visit(node ast.Node, functionContext *analyser.FunctionContext) {
+	if stmt, ok := node.(*ast.GoStmt); ok {
+		functionContext.AddMetric("goroutines", 1)
+		if functionContext.InLoop() {
+			functionContext.Report(stmt.Pos(), "go-in-loop", "starts a goroutine on every iteration")
+		}
+	}
+}
+
+func init() {
+	analyser.Register("go-in-loop", func() analyser.Analyser { return goInLoop{} })
+}
+
+func TestRegisteredAnalysers(t *testing.T) {
+	if !slices.Contains(analyser.Analysers(), "allocations") || !slices.Contains(analyser.Analysers(), "go-in-loop") {
+		t.Fatalf("expected the built-in and the test analysers to be registered, got %v", analyser.Analysers())
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "spawn.go", registrySample, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := analyser.RunFile(context.Background(), fset, file, analyser.Options{Analysers: []string{"allocations", "go-in-loop"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn := report.Functions[0]
+	if fn.Metrics["goroutines"] != 2 || fn.Metrics["allocations"] != 2 || fn.Metrics["loopAllocations"] != 1 {
+		t.Errorf("unexpected metrics %v", fn.Metrics)
+	}
+	expected := []analyser.Diagnostic{
+		{File: "spawn.go", Line: 5, Function: "spawn", Rule: "go-in-loop", Message: "starts a goroutine on every iteration"},
+		{File: "spawn.go", Line: 8, Function: "spawn", Rule: analyser.RuleAllocationInLoop, Message: "make allocates on every iteration of the loop at line 4, consider hoisting it"},
+	}
+	if !reflect.DeepEqual(report.Diagnostics, expected) {
+		t.Errorf("expected %+v, got %+v", expected, report.Diagnostics)
+	}
+
+	if _, err := analyser.RunFile(context.Background(), fset, file, analyser.Options{Analysers: []string{"missing"}}); err == nil {
+		t.Error("expected an error for an analyser that is not registered")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expected registering a name twice to panic")
+		}
+	}()
+	analyser.Register("allocations", func() analyser.Analyser { return goInLoop{} })
+}
@@ -0,0 +1,340 @@
+package test
+
+import (
+	"encoding/xml"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"github.com/DanyloPiatyhorets/funalyser/baseline"
+	"github.com/DanyloPiatyhorets/funalyser/report"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEvidence(t *testing.T) {
+	funcs, err := analyser.Analyse("test_data/membership_samples.go", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]analyser.Evidence{
+		"commonCalls": {
+			{Line: 7, Kind: analyser.EvidenceLoop, Message: "loop over len(as), O(n)", Time: 1},
+			{Line: 8, Kind: analyser.EvidenceCall, Message: "slices.Contains searches bs linearly, O(n^2)", Time: 2},
+		},
+		"allPairs": {
+			{Line: 30, Kind: analyser.EvidenceLoop, Message: "loop over len(as), O(n)", Time: 1},
+			{Line: 31, Kind: analyser.EvidenceLoop, Message: "loop over len(bs), O(n^2)", Time: 2},
+		},
+	}
+	for _, fn := range funcs {
+		want, ok := expected[fn.Name]
+		if ok && !reflect.DeepEqual(fn.Evidence, want) {
+			t.Errorf("evidence for %s: expected %+v, got %+v", fn.Name, want, fn.Evidence)
+		}
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	funcs, err := analyser.Analyse("test_data/membership_samples.go", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := report.WriteHTML(dir, funcs); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages, err := filepath.Glob(filepath.Join(dir, "files", "test_data_membership_samples.go-*.html"))
+	if err != nil || len(pages) != 1 {
+		t.Fatalf("expected a page for the file, got %v, %v", pages, err)
+	}
+	for _, text := range []string{`<td>test_data</td>`, `data-value="2">O(n^2)</td>`, `href="files/` + filepath.Base(pages[0]) + `"`} {
+		if !strings.Contains(string(index), text) {
+			t.Errorf("expected the index to contain %q", text)
+		}
+	}
+
+	page, err := os.ReadFile(pages[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{
+		`<section class="trail" id="fn-commonCalls-5">`,
+		`<li class="heat-2"><a href="#L8">line 8</a> slices.Contains searches bs linearly, O(n^2)</li>`,
+		`<span class="line heat-2" id="L31" title="loop over len(bs), O(n^2)">`,
+		`<span class="kw">func</span> commonCalls`,
+	} {
+		if !strings.Contains(string(page), text) {
+			t.Errorf("expected the file page to contain %q", text)
+		}
+	}
+
+	// a/b.go and a_b.go flatten to the same name and still get a page each
+	src, err := os.ReadFile("test_data/membership_samples.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := t.TempDir()
+	var alike []analyser.FunctionInfo
+	for _, path := range []string{filepath.Join(sources, "a", "b.go"), filepath.Join(sources, "a_b.go")} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, src, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		for _, fn := range funcs {
+			fn.File = path
+			alike = append(alike, fn)
+		}
+	}
+	dir = t.TempDir()
+	if err := report.WriteHTML(dir, alike); err != nil {
+		t.Fatal(err)
+	}
+	if pages, err := os.ReadDir(filepath.Join(dir, "files")); err != nil || len(pages) != 2 {
+		t.Errorf("expected a page for each of a/b.go and a_b.go, got %v, %v", pages, err)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	funcs, err := analyser.Analyse("test_data/membership_samples.go", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := baseline.New("test_data", funcs)
+	var kept []baseline.Entry
+	for _, entry := range old.Functions {
+		switch entry.Name {
+		case "commonCalls":
+			entry.Time = 1
+		case "allPairs":
+			entry.Name = "pairs"
+			entry.Time = 3
+		case "containsOnce":
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	old.Functions = kept
+
+	var out strings.Builder
+	if err := report.WriteMarkdown(&out, funcs, &old, "test_data"); err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{
+		"| Function | File | Time | Space | Fan-out | Change |",
+		"| `commonCalls` | `test_data/membership_samples.go:5` | O(n^2) | O(1) | 0 | 🔺 time O(n) → O(n^2) |",
+		"| `commonLoop` | `test_data/membership_samples.go:15` | O(n^2) | O(n^2) | 0 | = |",
+		"| `allPairs` | `test_data/membership_samples.go:28` | O(n^2) | O(1) | 0 | 🔻 time O(n^3) → O(n^2), renamed from `main.pairs` |",
+		"| `containsOnce` | `test_data/membership_samples.go:38` | O(n) | O(1) | 0 | new |",
+		"<details>\n<summary><code>commonCalls</code> O(len(as) · len(bs))</summary>\n\n- line 7: loop over len(as), O(n)\n",
+	} {
+		if !strings.Contains(out.String(), text) {
+			t.Errorf("expected the markdown to contain %q, got\n%s", text, out.String())
+		}
+	}
+}
+
+func TestRowWriter(t *testing.T) {
+	funcs, err := analyser.Analyse("test_data/membership_samples.go", "commonCalls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		report.FormatCSV:    "version,package,file,receiver,function,line,end_line,time,space,fan_out,confidence,lines_of_code\n1,main,test_data/membership_samples.go,,commonCalls,5,13,O(n^2),O(1),0,high,9\n",
+		report.FormatTSV:    "version\tpackage\tfile\treceiver\tfunction\tline\tend_line\ttime\tspace\tfan_out\tconfidence\tlines_of_code\n1\tmain\ttest_data/membership_samples.go\t\tcommonCalls\t5\t13\tO(n^2)\tO(1)\t0\thigh\t9\n",
+		report.FormatNDJSON: `{"version":1,"package":"main","file":"test_data/membership_samples.go","receiver":"","function":"commonCalls","line":5,"end_line":13,"time":"O(n^2)","space":"O(1)","fan_out":0,"confidence":"high","lines_of_code":9}` + "\n",
+	}
+	for format, want := range expected {
+		var out strings.Builder
+		rowWriter, err := report.NewRowWriter(&out, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rowWriter.Write(funcs[0]); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != want {
+			t.Errorf("%s: expected\n%s\ngot\n%s", format, want, out.String())
+		}
+	}
+}
+
+// ciDiagnostics are the diagnostics of the directive samples with an O(n) time threshold
+func ciDiagnostics(t *testing.T) ([]analyser.FunctionInfo, []analyser.Diagnostic) {
+	funcs, err := analyser.Analyse("test_data/directive_samples.go", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	linear := float32(1)
+	var diagnostics []analyser.Diagnostic
+	for _, fn := range funcs {
+		diagnostics = append(diagnostics, fn.Diagnostics...)
+		diagnostics = append(diagnostics, analyser.Thresholds{Time: &linear}.Check(fn)...)
+	}
+	diagnostics = append(diagnostics, analyser.Diagnostic{File: "test_data/broken.go", Line: 3, Rule: analyser.RuleSyntax, Message: "expected ';', found 'EOF'"})
+	return funcs, diagnostics
+}
+
+func TestWriteJUnit(t *testing.T) {
+	funcs, diagnostics := ciDiagnostics(t)
+	var out strings.Builder
+	if err := report.WriteJUnit(&out, funcs, diagnostics); err != nil {
+		t.Fatal(err)
+	}
+
+	var suites struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Suites   []struct {
+			Name  string `xml:"name,attr"`
+			Cases []struct {
+				Name     string `xml:"name,attr"`
+				Line     int    `xml:"line,attr"`
+				Failures []struct {
+					Type string `xml:"type,attr"`
+				} `xml:"failure"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal([]byte(out.String()), &suites); err != nil {
+		t.Fatal(err)
+	}
+	if suites.Tests != len(funcs)+1 || suites.Failures != 3 || len(suites.Suites) != 2 {
+		t.Fatalf("expected %d tests with 3 failures in 2 suites, got\n%s", len(funcs)+1, out.String())
+	}
+	failures := map[string][]string{}
+	for _, suite := range suites.Suites {
+		for _, testCase := range suite.Cases {
+			for _, failure := range testCase.Failures {
+				failures[testCase.Name] = append(failures[testCase.Name], failure.Type)
+			}
+		}
+	}
+	expected := map[string][]string{
+		"contradictsExpectation": {analyser.RuleExpect, analyser.RuleThreshold},
+		"malformedDirectives":    {analyser.RuleDirective, analyser.RuleDirective},
+		"test_data/broken.go":    {analyser.RuleSyntax},
+	}
+	if !reflect.DeepEqual(failures, expected) {
+		t.Errorf("expected failures %v, got %v", expected, failures)
+	}
+}
+
+func TestWriteCheckstyle(t *testing.T) {
+	funcs, diagnostics := ciDiagnostics(t)
+	var out strings.Builder
+	if err := report.WriteCheckstyle(&out, funcs, diagnostics); err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{
+		`<file name="test_data/directive_samples.go">`,
+		`<error line="13" severity="error" message="contradictsExpectation: time complexity O(n^2) exceeds the threshold of O(n)" source="funalyser.threshold"></error>`,
+		`<error line="46" severity="error" message="malformedDirectives: unknown directive &#34;frobnicate&#34;" source="funalyser.directive"></error>`,
+		`<file name="test_data/broken.go">`,
+	} {
+		if !strings.Contains(out.String(), text) {
+			t.Errorf("expected the checkstyle report to contain %q, got\n%s", text, out.String())
+		}
+	}
+}
+
+func TestAdvisoryFindings(t *testing.T) {
+	funcs, err := analyser.Analyse("test_data/fix_samples.go", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var diagnostics []analyser.Diagnostic
+	for _, fn := range funcs {
+		diagnostics = append(diagnostics, fn.Diagnostics...)
+	}
+	if len(diagnostics) == 0 {
+		t.Fatal("expected prealloc findings in fix_samples.go")
+	}
+	for _, diagnostic := range diagnostics {
+		if !diagnostic.Advisory() {
+			t.Errorf("expected %s:%d [%s] to be advisory", diagnostic.File, diagnostic.Line, diagnostic.Rule)
+		}
+	}
+
+	var junit strings.Builder
+	if err := report.WriteJUnit(&junit, funcs, diagnostics); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(junit.String(), `failures="0"`) || strings.Contains(junit.String(), "<failure") || !strings.Contains(junit.String(), "<system-out>test_data/fix_samples.go:16: result grows by append") {
+		t.Errorf("expected advisory findings in system-out and no failures, got\n%s", junit.String())
+	}
+	var checkstyle strings.Builder
+	if err := report.WriteCheckstyle(&checkstyle, funcs, diagnostics); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(checkstyle.String(), `severity="warning"`) || strings.Contains(checkstyle.String(), `severity="error"`) {
+		t.Errorf("expected advisory findings to be warnings, got\n%s", checkstyle.String())
+	}
+
+	output, err := exec.Command("go", "run", "..", "check", "test_data/fix_samples.go").CombinedOutput()
+	if err != nil {
+		t.Fatalf("expected check to pass with only advisory findings: %v\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "[prealloc]") {
+		t.Errorf("expected check to still print the advisory findings, got\n%s", output)
+	}
+}
+
+func TestGraph(t *testing.T) {
+	funcs, err := analyser.Analyse("test_data/call_samples.go", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph := report.NewGraph(funcs)
+
+	nodes := map[string]report.Node{}
+	for _, node := range graph.Nodes {
+		nodes[node.Name] = node
+	}
+	sumInLoop := nodes["sumInLoop"]
+	if sumInLoop.Own.Time != "O(n)" || sumInLoop.Cumulative.Time != "O(n^2)" {
+		t.Errorf("expected sumInLoop to cost O(n) on its own and O(n^2) with sumItems, got %+v and %+v", sumInLoop.Own, sumInLoop.Cumulative)
+	}
+	if isEven := nodes["isEven"]; isEven.Cumulative.Time != "O(1)" {
+		t.Errorf("expected the recursion of isEven and isOdd to be cut, got %+v", isEven.Cumulative)
+	}
+
+	expected := []report.Edge{
+		{From: "test_data.sumInLoop", To: "test_data.sumItems", Lines: []int{14}, Times: "O(len(items))"},
+		{From: "test_data.isEven", To: "test_data.isOdd", Lines: []int{23}, Times: "O(1)"},
+		{From: "test_data.isOdd", To: "test_data.isEven", Lines: []int{30}, Times: "O(1)"},
+	}
+	if len(graph.Edges) != len(expected) {
+		t.Fatalf("expected %d edges, got %+v", len(expected), graph.Edges)
+	}
+	for i, edge := range graph.Edges {
+		want := expected[i]
+		if edge.From != want.From || edge.To != want.To || !reflect.DeepEqual(edge.Lines, want.Lines) || edge.Times != want.Times {
+			t.Errorf("expected edge %+v, got %+v", want, edge)
+		}
+	}
+
+	var dot strings.Builder
+	if err := report.WriteDOT(&dot, graph); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(dot.String(), `"test_data.sumInLoop" -> "test_data.sumItems" [label="called O(len(items)) times", penwidth=2];`) {
+		t.Errorf("expected the DOT graph to label the call in the loop, got\n%s", dot.String())
+	}
+	var mermaid strings.Builder
+	if err := report.WriteMermaid(&mermaid, graph); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(mermaid.String(), `-->|"called O(len(items)) times"|`) || !strings.Contains(mermaid.String(), "cumulative O(n^2) time") {
+		t.Errorf("expected the Mermaid flowchart to label nodes and edges, got\n%s", mermaid.String())
+	}
+}
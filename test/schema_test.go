@@ -0,0 +1,210 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"github.com/DanyloPiatyhorets/funalyser/baseline"
+	"github.com/DanyloPiatyhorets/funalyser/report"
+	"github.com/DanyloPiatyhorets/funalyser/verify"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestJSONMatchesSchema(t *testing.T) {
+	var schema map[string]any
+	if err := json.Unmarshal(report.Schema, &schema); err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob("test_data/*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		funcs, err := analyser.Analyse(file, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		diagnostics := []analyser.Diagnostic{{File: file, Rule: analyser.RuleSyntax, Message: "expected ';'"}}
+		changes := report.Changes{SchemaVersion: report.SchemaVersion, Changes: []report.Change{}}
+		for _, fn := range funcs {
+			diagnostics = append(diagnostics, fn.Diagnostics...)
+			changes.Changes = append(changes.Changes, report.NewChange(fn, "changed", nil), report.NewChange(fn, "calls main.f", &fn))
+		}
+		documents := []any{report.NewDocument(funcs, diagnostics[:1]), changes, report.NewCheck(diagnostics), report.NewGraph(funcs)}
+		for _, document := range documents {
+			for _, problem := range validateJSON(t, schema, document) {
+				t.Errorf("%s: %T: %s", file, document, problem)
+			}
+		}
+	}
+
+	verification := report.NewVerification(&verify.Result{
+		Function:   "BubbleSort",
+		Static:     analyser.Complexity{Time: 2},
+		Measured:   analyser.Complexity{Time: 2},
+		Samples:    []verify.Sample{{N: 16, NsPerOp: 523.5, BytesPerOp: 24, AllocsPerOp: 1}},
+		TimeAgrees: true,
+	})
+	for _, problem := range validateJSON(t, schema, verification) {
+		t.Errorf("verification: %s", problem)
+	}
+
+	old := baseline.Entry{Package: "cmd", Name: "parse", File: "cmd/parse.go", Line: 3, Time: 1}
+	renamed := baseline.Entry{Package: "cmd", Receiver: "parser", Name: "parseAll", File: "cmd/parser.go", Line: 8, Time: 2, Space: 1}
+	regressions := report.NewRegressions([]baseline.Regression{
+		{Old: old, New: baseline.Entry{Package: "cmd", Name: "parse", File: "cmd/parse.go", Line: 5, Time: 2}, Match: baseline.MatchExact},
+		{Old: old, New: renamed, Match: baseline.MatchRenamed},
+	})
+	if regressions.Regressions[0].Previous != "" || regressions.Regressions[1].Previous != "cmd.parse" {
+		t.Errorf("expected only the renamed function to name its baseline entry, got %+v", regressions.Regressions)
+	}
+	for _, document := range []any{regressions, report.NewRegressions(nil)} {
+		for _, problem := range validateJSON(t, schema, document) {
+			t.Errorf("regressions: %s", problem)
+		}
+	}
+
+	analysis := schema["$defs"].(map[string]any)["analysis"].(map[string]any)
+	invalid := map[string]any{"schemaVersion": 2.0, "functions": []any{map[string]any{"name": "f", "Complexity": 1.5}}}
+	if problems := validate(schema, analysis, invalid, ""); len(problems) < 3 {
+		t.Errorf("expected the wrong version, the missing fields and the unknown field to be reported, got %v", problems)
+	}
+	if problems := validate(schema, schema, invalid, ""); len(problems) == 0 {
+		t.Error("expected an invalid document to match none of the outputs")
+	}
+}
+
+// validateJSON encodes a document like the commands do and validates it against the schema
+func validateJSON(t *testing.T, schema map[string]any, document any) []string {
+	jsonBytes, err := json.Marshal(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded any
+	if err := json.Unmarshal(jsonBytes, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return validate(schema, schema, decoded, "")
+}
+
+func TestJSONExpressions(t *testing.T) {
+	function := report.NewFunction(analyser.FunctionInfo{
+		Complexity: analyser.Complexity{Time: 1.5, Space: 2},
+		Terms:      []analyser.Term{{{Input: "len(users)", Param: "users", Index: 1}, {Input: "limit", Param: "limit", Index: 0.5}}},
+		Unresolved: []analyser.Unresolved{{Line: 3, Kind: analyser.UnresolvedCall, Message: "call to f has an unknown cost"}},
+	})
+	n := report.Expression{Kind: report.ExpressionVariable, Name: "n"}
+	expected := []struct {
+		complexity report.Complexity
+		class      string
+		expression report.Expression
+	}{
+		{function.Time, "O(n*log n)", report.Expression{Kind: report.ExpressionProduct, Operands: []report.Expression{n, {Kind: report.ExpressionLog, Operands: []report.Expression{n}}}}},
+		{function.Space, "O(n^2)", report.Expression{Kind: report.ExpressionPower, Value: 2, Operands: []report.Expression{n}}},
+		{function.TimeByInput, "O(len(users) · log limit)", report.Expression{Kind: report.ExpressionProduct, Operands: []report.Expression{
+			{Kind: report.ExpressionVariable, Name: "len(users)"},
+			{Kind: report.ExpressionLog, Operands: []report.Expression{{Kind: report.ExpressionVariable, Name: "limit"}}},
+		}}},
+	}
+	for _, want := range expected {
+		if want.complexity.Class != want.class || !want.complexity.LowerBound || !reflect.DeepEqual(want.complexity.Expression, want.expression) {
+			t.Errorf("expected lower bound %s as %+v, got %+v", want.class, want.expression, want.complexity)
+		}
+	}
+}
+
+// validate checks a value against the subset of JSON Schema the schema uses
+func validate(root map[string]any, schema map[string]any, value any, path string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		definition := root["$defs"].(map[string]any)[strings.TrimPrefix(ref, "#/$defs/")]
+		if definition == nil {
+			return []string{path + ": unknown $ref " + ref}
+		}
+		return validate(root, definition.(map[string]any), value, path)
+	}
+	var problems []string
+	if oneOf, ok := schema["oneOf"].([]any); ok {
+		matches := 0
+		for _, option := range oneOf {
+			if len(validate(root, option.(map[string]any), value, path)) == 0 {
+				matches++
+			}
+		}
+		if matches != 1 {
+			problems = append(problems, fmt.Sprintf("%s: expected to match one schema of oneOf, matches %d", path, matches))
+		}
+	}
+	if constant, ok := schema["const"]; ok && constant != value {
+		problems = append(problems, fmt.Sprintf("%s: expected %v, got %v", path, constant, value))
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		problems = append(problems, fmt.Sprintf("%s: %v is not one of %v", path, value, enum))
+	}
+	if minimum, ok := schema["minimum"].(float64); ok {
+		if number, ok := value.(float64); ok && number < minimum {
+			problems = append(problems, fmt.Sprintf("%s: %v is below %v", path, number, minimum))
+		}
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return append(problems, fmt.Sprintf("%s: expected an object, got %v", path, value))
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		for _, required := range asSlice(schema["required"]) {
+			if _, ok := object[required.(string)]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: missing %s", path, required))
+			}
+		}
+		for name, field := range object {
+			if property, ok := properties[name]; ok {
+				problems = append(problems, validate(root, property.(map[string]any), field, path+"."+name)...)
+			} else if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+				problems = append(problems, validate(root, additional, field, path+"."+name)...)
+			} else if schema["additionalProperties"] == false {
+				problems = append(problems, fmt.Sprintf("%s: %s is not in the schema", path, name))
+			}
+		}
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			return append(problems, fmt.Sprintf("%s: expected an array, got %v", path, value))
+		}
+		if minItems, ok := schema["minItems"].(float64); ok && float64(len(array)) < minItems {
+			problems = append(problems, fmt.Sprintf("%s: expected at least %v items", path, minItems))
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range array {
+				problems = append(problems, validate(root, items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case "string", "boolean", "number", "integer":
+		if !hasType(value, schema["type"].(string)) {
+			problems = append(problems, fmt.Sprintf("%s: expected a %s, got %v", path, schema["type"], value))
+		}
+	}
+	return problems
+}
+
+func hasType(value any, schemaType string) bool {
+	switch value := value.(type) {
+	case string:
+		return schemaType == "string"
+	case bool:
+		return schemaType == "boolean"
+	case float64:
+		return schemaType == "number" || schemaType == "integer" && value == float64(int64(value))
+	}
+	return false
+}
+
+func asSlice(value any) []any {
+	slice, _ := value.([]any)
+	return slice
+}
@@ -0,0 +1,63 @@
+package test
+
+import (
+	"bytes"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSourceEntryPoints(t *testing.T) {
+	const path = "test_data/time_samples.go"
+	expected, err := analyser.Analyse(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromSource, err := analyser.AnalyseSource(path, src, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromReader, err := analyser.AnalyseReader(path, bytes.NewReader(src), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromFile, err := analyser.AnalyseFile(fset, file, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromFS, err := analyser.AnalyseFS(fstest.MapFS{path: {Data: src}}, path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, funcsInfo := range map[string][]analyser.FunctionInfo{"source": fromSource, "reader": fromReader, "file": fromFile, "fs": fromFS} {
+		if !reflect.DeepEqual(complexities(expected), complexities(funcsInfo)) {
+			t.Errorf("%s: expected %v, got %v", name, complexities(expected), complexities(funcsInfo))
+		}
+		if funcsInfo[0].File != path {
+			t.Errorf("%s: expected positions in %s, got %s", name, path, funcsInfo[0].File)
+		}
+	}
+}
+
+func complexities(funcsInfo []analyser.FunctionInfo) map[string]analyser.Complexity {
+	byName := map[string]analyser.Complexity{}
+	for _, fn := range funcsInfo {
+		byName[fn.QualifiedName()] = fn.Complexity
+	}
+	return byName
+}
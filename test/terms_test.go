@@ -0,0 +1,42 @@
+package test
+
+import (
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"testing"
+)
+
+func TestTimeByInput(t *testing.T) {
+	funcs, err := analyser.Analyse("test_data/input_samples.go", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"page":     "O(len(users) · limit)",
+		"both":     "O(n^2)",
+		"twoLists": "O(len(a) + len(b))",
+		"count":    "O(n)",
+	}
+	for _, fn := range funcs {
+		if fn.Name == "search" {
+			// the halving recursion is not understood, O(1) is only a lower bound
+			if fn.Confidence != analyser.ConfidenceLow || analyser.FormatLowerBound(fn.Complexity.Time, fn.Unresolved) != "≥ O(1), unknown contributions at line 39" {
+				t.Errorf("time of search: expected a low confidence lower bound, got %s with %s confidence", analyser.FormatLowerBound(fn.Complexity.Time, fn.Unresolved), fn.Confidence)
+			}
+			continue
+		}
+		want, ok := expected[fn.Name]
+		if !ok {
+			t.Errorf("No expected result for %s", fn.Name)
+			continue
+		}
+		if fn.TimeByInput != want {
+			t.Errorf("time of %s by input: expected %s, got %s", fn.Name, want, fn.TimeByInput)
+		}
+	}
+
+	terms := []analyser.Term{{{Input: "|m|", Param: "m", Index: 1}}, {{Input: "n", Param: "n", Index: 1.5}}}
+	if got := analyser.FormatTerms(terms); got != "O(|m| + n*log n)" {
+		t.Errorf("unexpected terms %q", got)
+	}
+}
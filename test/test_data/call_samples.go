@@ -0,0 +1,31 @@
+package main
+
+func sumItems(items []int) int {
+	total := 0
+	for _, item := range items {
+		total += item
+	}
+	return total
+}
+
+func sumInLoop(items []int) int {
+	total := 0
+	for range items {
+		total += sumItems(items)
+	}
+	return total
+}
+
+func isEven(n int) bool {
+	if n == 0 {
+		return true
+	}
+	return isOdd(n - 1)
+}
+
+func isOdd(n int) bool {
+	if n == 0 {
+		return false
+	}
+	return isEven(n - 1)
+}
@@ -0,0 +1,43 @@
+package main
+
+import "strings"
+
+func joinWords(words []string) string {
+	s := ""
+	for _, word := range words {
+		s += word + " "
+	}
+	return s
+}
+
+func joinLines(lines []string) string {
+	out := ""
+	for i := 0; i < len(lines); i++ {
+		out = out + lines[i] + "\n"
+	}
+	return out
+}
+
+func sumNumbers(numbers []int) int {
+	total := 0
+	for _, number := range numbers {
+		total += number
+	}
+	return total
+}
+
+func fixedConcat() string {
+	s := ""
+	for i := 0; i < 3; i++ {
+		s += "x"
+	}
+	return s
+}
+
+func builderJoin(words []string) string {
+	var builder strings.Builder
+	for _, word := range words {
+		builder.WriteString(word)
+	}
+	return builder.String()
+}
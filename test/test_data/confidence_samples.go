@@ -0,0 +1,105 @@
+package main
+
+type Store interface {
+	Find(key string) int
+}
+
+func sumSlice(items []int) int {
+	total := 0
+	for _, item := range items {
+		total += item
+	}
+	return total
+}
+
+func findAll(store Store, keys []string) int {
+	found := 0
+	for _, key := range keys {
+		found += store.Find(key)
+	}
+	return found
+}
+
+func drainQueue(queue []int) int {
+	total := 0
+	for len(queue) > 0 {
+		total += queue[0]
+		queue = queue[1:]
+	}
+	return total
+}
+
+func countedLoop(items []int) int {
+	count := len(items)
+	total := 0
+	for i := 0; i < count; i++ {
+		total += items[i]
+	}
+	return total
+}
+
+func copyItems(items []int) []int {
+	copied := make([]int, len(items))
+	copy(copied, items)
+	return copied
+}
+
+func sizeOfAny(value any, items []int) int {
+	switch value.(type) {
+	case string:
+		total := 0
+		for _, item := range items {
+			total += item
+		}
+		return total
+	}
+	return 0
+}
+
+func sumOrStop(items []int, stop chan bool) int {
+	total := 0
+	select {
+	case <-stop:
+		return 0
+	default:
+		for _, item := range items {
+			total += item
+		}
+	}
+	return total
+}
+
+func sumInBackground(items []int, result chan int) {
+	go func() {
+		total := 0
+		for _, item := range items {
+			total += item
+		}
+		result <- total
+	}()
+}
+
+func sumOnReturn(items []int, result *int) {
+	defer func() {
+		for _, item := range items {
+			*result += item
+		}
+	}()
+}
+
+func zeroes(n int) []int {
+	var zeroed = make([]int, n)
+	return zeroed
+}
+
+func reversedCopy(items []int) []int {
+	reversed := make([]int, 0, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		reversed = append(reversed, items[i])
+	}
+	return reversed
+}
+
+func reversedLength(items []int) int {
+	return len(reversedCopy(items))
+}
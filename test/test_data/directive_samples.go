@@ -0,0 +1,48 @@
+package main
+
+//funalyser:expect time=O(n) space=O(1)
+func matchesExpectation(items []int) int {
+	total := 0
+	for _, item := range items {
+		total += item
+	}
+	return total
+}
+
+//funalyser:expect time=O(n log n)
+func contradictsExpectation(n int) {
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			println(i, j)
+		}
+	}
+}
+
+//funalyser:expect time=O(1)
+//funalyser:ignore
+func ignoredExpectation(items []int) {
+	for _, item := range items {
+		println(item)
+	}
+}
+
+//funalyser:assume n<=64
+func assumedBound(n int, items []int) {
+	for i := 0; i < n; i++ {
+		for _, item := range items {
+			println(i, item)
+		}
+	}
+}
+
+//funalyser:assume len(items) < 16
+func assumedLength(items []int) {
+	for _, item := range items {
+		println(item)
+	}
+}
+
+//funalyser:expect speed=O(n)
+//funalyser:frobnicate
+func malformedDirectives() {
+}
@@ -0,0 +1,44 @@
+package main
+
+import "encoding/json"
+
+func squaresUpTo(n int) []int {
+	out := []int{}
+	for i := 0; i < n; i++ {
+		out = append(out, i*i)
+	}
+	return out
+}
+
+func userNames(users map[string]int) []string {
+	var result []string
+	for name := range users {
+		result = append(result, name)
+	}
+	return result
+}
+
+func doubledAfterReset(items []int) []int {
+	out := make([]int, 0)
+	out = nil
+	for _, item := range items {
+		out = append(out, item*2)
+	}
+	return out
+}
+
+type versioned struct{ Version int }
+
+func (v *versioned) Bump() {
+	v.Version++
+}
+
+func encodeVersions(current *versioned, times int) [][]byte {
+	var encoded [][]byte
+	for i := 0; i < times; i++ {
+		current.Bump()
+		data, _ := json.Marshal(current)
+		encoded = append(encoded, data)
+	}
+	return encoded
+}
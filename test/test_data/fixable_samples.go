@@ -0,0 +1,23 @@
+package main
+
+import "slices"
+
+func namesAfterLoading(load func() []string) []string {
+	names := []string{}
+	loaded := load()
+	for _, name := range loaded {
+		names = append(names, name)
+	}
+	return names
+}
+
+func sharedIDs(as []int, bs []int) int {
+	bsSet := len(bs)
+	shared := 0
+	for _, a := range as {
+		if slices.Contains(bs, a) {
+			shared++
+		}
+	}
+	return shared + bsSet
+}
@@ -0,0 +1,47 @@
+package main
+
+type Account struct{ Name string }
+
+func page(users []Account, limit int) {
+	for _, u := range users {
+		for i := 0; i < limit; i++ {
+			println(u.Name, i)
+		}
+	}
+}
+
+func both(m map[string]int, n int) {
+	for k := range m {
+		println(k)
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			println(i, j)
+		}
+	}
+}
+
+func twoLists(a []int, b []int) {
+	for range a {
+	}
+	for _, x := range a {
+		println(x)
+	}
+	for _, y := range b {
+		println(y)
+	}
+}
+
+func search(items []int, lo, hi int) int {
+	if lo >= hi {
+		return lo
+	}
+	return search(items, (lo+hi)/2, hi)
+}
+
+func count(n int) int {
+	if n == 0 {
+		return 0
+	}
+	return count(n-1) + 1
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"time"
+)
+
+func matchLines(lines []string) int {
+	count := 0
+	for _, line := range lines {
+		pattern := regexp.MustCompile(`^\d+$`)
+		if pattern.MatchString(line) {
+			count++
+		}
+	}
+	return count
+}
+
+func matchLinesHoisted(lines []string) int {
+	pattern := regexp.MustCompile(`^\d+$`)
+	count := 0
+	for _, line := range lines {
+		if pattern.MatchString(line) {
+			count++
+		}
+	}
+	return count
+}
+
+func encodeEach(values []int) [][]byte {
+	var encoded [][]byte
+	for _, value := range values {
+		data, _ := json.Marshal(value)
+		encoded = append(encoded, data)
+	}
+	return encoded
+}
+
+func closeAll(paths []string) {
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		defer file.Close()
+	}
+}
+
+type counter struct{ Count int }
+
+func (c *counter) Increment() {
+	c.Count++
+}
+
+func encodeCounts(current *counter, times int) [][]byte {
+	var encoded [][]byte
+	for i := 0; i < times; i++ {
+		current.Increment()
+		data, _ := json.Marshal(current)
+		encoded = append(encoded, data)
+	}
+	return encoded
+}
+
+func localTimes(stamps []time.Time) []time.Time {
+	var local []time.Time
+	for _, stamp := range stamps {
+		zone, _ := time.LoadLocation("Europe/Paris")
+		local = append(local, stamp.In(zone))
+	}
+	return local
+}
+
+func matchPattern(lines []string, pattern string) int {
+	count := 0
+	for _, line := range lines {
+		if regexp.MustCompile(pattern).MatchString(line) {
+			count++
+		}
+	}
+	return count
+}
+
+type settings struct{ Name string }
+
+func encodeSettings(cfg *settings, times int) [][]byte {
+	var encoded [][]byte
+	for i := 0; i < times; i++ {
+		data, _ := json.Marshal(cfg)
+		encoded = append(encoded, data)
+	}
+	return encoded
+}
+
+func rename(cfg *settings, name string) {
+	cfg.Name = name
+}
+
+func encodeRenamed(cfg *settings, names []string) [][]byte {
+	var encoded [][]byte
+	for _, name := range names {
+		rename(cfg, name)
+		data, _ := json.Marshal(cfg)
+		encoded = append(encoded, data)
+	}
+	return encoded
+}
+
+var zoneName = "Europe/Paris"
+
+func localTimesIn(stamps []time.Time) []time.Time {
+	var local []time.Time
+	for _, stamp := range stamps {
+		zone, _ := time.LoadLocation(zoneName)
+		local = append(local, stamp.In(zone))
+	}
+	return local
+}
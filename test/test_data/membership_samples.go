@@ -0,0 +1,40 @@
+package main
+
+import "slices"
+
+func commonCalls(as, bs []int) int {
+	count := 0
+	for _, a := range as {
+		if slices.Contains(bs, a) {
+			count++
+		}
+	}
+	return count
+}
+
+func commonLoop(as, bs []string) []string {
+	var common []string
+	for _, a := range as {
+		for _, b := range bs {
+			if a == b {
+				common = append(common, a)
+				break
+			}
+		}
+	}
+	return common
+}
+
+func allPairs(as, bs []int) int {
+	sum := 0
+	for _, a := range as {
+		for _, b := range bs {
+			sum += a * b
+		}
+	}
+	return sum
+}
+
+func containsOnce(bs []int, x int) bool {
+	return slices.Contains(bs, x)
+}
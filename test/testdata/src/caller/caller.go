@@ -0,0 +1,14 @@
+package caller
+
+import "sorting"
+
+func SortAll(batches [][]int) { // want SortAll:"time=O\\(n\\^3\\) space=O\\(1\\)" `time complexity O\(n\^3\) exceeds the threshold of O\(n\)`
+	for _, batch := range batches {
+		sorting.BubbleSort(batch)
+	}
+}
+
+//funalyser:ignore threshold
+func SortOnce(batch []int) { // want SortOnce:"time=O\\(n\\^2\\) space=O\\(1\\)"
+	sorting.BubbleSort(batch)
+}
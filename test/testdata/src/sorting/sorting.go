@@ -0,0 +1,25 @@
+package sorting
+
+func BubbleSort(array []int) []int { // want BubbleSort:"time=O\\(n\\^2\\) space=O\\(1\\)" `time complexity O\(n\^2\) exceeds the threshold of O\(n\)`
+	for i := 0; i < len(array)-1; i++ {
+		for j := 0; j < len(array)-i-1; j++ {
+			if array[j] > array[j+1] {
+				array[j], array[j+1] = array[j+1], array[j]
+			}
+		}
+	}
+	return array
+}
+
+func Sum(items []int) int { // want Sum:"time=O\\(n\\) space=O\\(1\\)"
+	total := 0
+	for _, item := range items {
+		total += item
+	}
+	return total
+}
+
+//funalyser:expect time=O(1)
+func Mismatch(items []int) int { // want Mismatch:"time=O\\(n\\) space=O\\(1\\)" `expected time complexity O\(1\), inferred O\(n\)`
+	return Sum(items)
+}
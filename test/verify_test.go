@@ -0,0 +1,97 @@
+package test
+
+import (
+	"errors"
+	"github.com/DanyloPiatyhorets/funalyser/verify"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFit(t *testing.T) {
+	sizes := []int{16, 32, 64, 128, 256, 512, 1024}
+	curves := map[float32]func(n float64) float64{
+		0:   func(n float64) float64 { return 40 },
+		1:   func(n float64) float64 { return 30 + 2*n },
+		1.5: func(n float64) float64 { return 10 + n*math.Log2(n) },
+		2:   func(n float64) float64 { return 200 + 0.5*n*n },
+		2.5: func(n float64) float64 { return n * n * math.Log2(n) },
+		3:   func(n float64) float64 { return n * n * n },
+	}
+	random := rand.New(rand.NewSource(1))
+
+	for want, curve := range curves {
+		var values []float64
+		for _, size := range sizes {
+			// up to 5% measurement noise
+			values = append(values, curve(float64(size))*(1+0.1*(random.Float64()-0.5)))
+		}
+		if got := verify.Fit(sizes, values); got != want {
+			t.Errorf("expected class %f, got %f for %v", want, got, values)
+		}
+	}
+}
+
+func TestParseBenchmarks(t *testing.T) {
+	output := []byte(`goos: linux
+BenchmarkFunalyserVerify/n=16-8         	  926104	      1290 ns/op	     128 B/op	       1 allocs/op
+BenchmarkFunalyserVerify/n=32-8         	  452917	      2610 ns/op	     256 B/op	       1 allocs/op
+BenchmarkFunalyserVerify/n=16-8         	  726104	      1490 ns/op	     128 B/op	       1 allocs/op
+PASS
+`)
+	samples, err := verify.ParseBenchmarks(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []verify.Sample{{N: 16, NsPerOp: 1290, BytesPerOp: 128, AllocsPerOp: 1}, {N: 32, NsPerOp: 2610, BytesPerOp: 256, AllocsPerOp: 1}}
+	if len(samples) != len(expected) || samples[0] != expected[0] || samples[1] != expected[1] {
+		t.Errorf("expected %v, got %v", expected, samples)
+	}
+}
+
+func TestHarness(t *testing.T) {
+	harness, err := verify.Harness("test_data/space_samples.go", "mapSpace", []int{8, 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(harness), "funalyserSink = mapSpace(input.argument0)") {
+		t.Errorf("unexpected harness:\n%s", harness)
+	}
+
+	if _, err := verify.Harness("test_data/space_samples.go", "missing", []int{8}); err == nil {
+		t.Error("expected an error for a missing function")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test -bench")
+	}
+	options := verify.Options{Sizes: []int{32, 64, 128, 256, 512}, BenchTime: 50 * time.Millisecond, Count: 5, Timeout: time.Minute}
+	// SelectionSort branches the same way whatever the input, unlike BubbleSort whose swaps
+	// mispredict less and less as inputs grow, which only looks quadratic on large inputs
+	result, err := verify.Verify("test_data/sorting_samples.go", "SelectionSort", options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.TimeAgrees {
+		t.Errorf("expected the measured time complexity of SelectionSort to agree, got %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join("test_data", verify.HarnessFile)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the harness to stay out of the package, got %v", err)
+	}
+}
+
+func TestVerifyAmbiguous(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "sums.go")
+	if err := os.WriteFile(file, []byte("package sums\n\nfunc Sum(n int) int { return n }\n\nfunc sum(n int) int { return n }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verify.Verify(file, "SUM", verify.DefaultOptions()); err == nil || !strings.Contains(err.Error(), "2 functions match") {
+		t.Errorf("expected verify to refuse a name matching two functions, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package verify
+
+import (
+	"math"
+)
+
+// Sample is the measured cost of one call with inputs of size N
+type Sample struct {
+	N           int
+	NsPerOp     float64
+	BytesPerOp  float64
+	AllocsPerOp float64
+}
+
+type class struct {
+	index  float32
+	growth func(n float64) float64
+}
+
+// candidates are the complexity classes measurements are fitted to
+var candidates = []class{
+	{0, func(n float64) float64 { return 1 }},
+	{0.5, func(n float64) float64 { return math.Log2(n) }},
+	{1, func(n float64) float64 { return n }},
+	{1.5, func(n float64) float64 { return n * math.Log2(n) }},
+	{2, func(n float64) float64 { return n * n }},
+	{2.5, func(n float64) float64 { return n * n * math.Log2(n) }},
+	{3, func(n float64) float64 { return n * n * n }},
+}
+
+// Fit finds the complexity class whose growth best explains how values grow with sizes.
+// Fixed overheads dominate small inputs, so only the larger half of the sizes is used:
+// the slope of log(value) against log(n) there is compared with the slope every class has
+// over the same sizes, e.g. 1 for O(n) and a little above 2 for O(n^2*log n)
+func Fit(sizes []int, values []float64) float32 {
+	if len(sizes) < 3 || allBelow(values, 1) {
+		return 0
+	}
+	from := len(sizes) / 2
+	if len(sizes)-from < 3 {
+		from = len(sizes) - 3
+	}
+	sizes, values = sizes[from:], values[from:]
+
+	measured := logLogSlope(sizes, func(i int) float64 { return math.Max(values[i], 1) })
+	best := candidates[0]
+	bestDistance := math.Inf(1)
+	for _, candidate := range candidates {
+		expected := logLogSlope(sizes, func(i int) float64 { return candidate.growth(float64(sizes[i])) })
+		if distance := math.Abs(measured - expected); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best.index
+}
+
+func logLogSlope(sizes []int, value func(i int) float64) float64 {
+	count := float64(len(sizes))
+	var sumX, sumY, sumXX, sumXY float64
+	for i, size := range sizes {
+		x, y := math.Log(float64(size)), math.Log(value(i))
+		sumX += x
+		sumY += y
+		sumXX += x * x
+		sumXY += x * y
+	}
+	denominator := count*sumXX - sumX*sumX
+	if math.Abs(denominator) < 1e-12 {
+		return 0
+	}
+	return (count*sumXY - sumX*sumY) / denominator
+}
+
+func allBelow(values []float64, limit float64) bool {
+	for _, value := range values {
+		if value >= limit {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,435 @@
+package verify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	analyser "github.com/DanyloPiatyhorets/funalyser/analyser/go"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// HarnessFile is the benchmark added to the copy of the verified function's package
+const HarnessFile = "funalyser_verify_test.go"
+
+type Options struct {
+	// Sizes are the input sizes the function is benchmarked with, in increasing order
+	Sizes     []int
+	BenchTime time.Duration
+	// Count is how often every size is benchmarked, the fastest run is kept as the others
+	// were slowed down by noise like the scheduler or the garbage collector
+	Count   int
+	Timeout time.Duration
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Sizes:     []int{16, 32, 64, 128, 256, 512, 1024},
+		BenchTime: 100 * time.Millisecond,
+		Count:     3,
+		Timeout:   5 * time.Minute,
+	}
+}
+
+type Result struct {
+	Function    string
+	Static      analyser.Complexity
+	Measured    analyser.Complexity
+	Samples     []Sample
+	TimeAgrees  bool
+	SpaceAgrees bool
+}
+
+// Verify benchmarks a function with inputs of increasing size and fits the timings and
+// allocations to complexity classes, to check the static analysis against reality.
+// The harness runs in a temporary copy of the function's package, which is left untouched
+func Verify(filePath string, functionName string, options Options) (*Result, error) {
+	funcsInfo, err := analyser.Analyse(filePath, functionName)
+	if err != nil {
+		return nil, err
+	}
+	if len(funcsInfo) > 1 {
+		var names []string
+		for _, fn := range funcsInfo {
+			names = append(names, fn.QualifiedName())
+		}
+		return nil, fmt.Errorf("%d functions match %q, name one of %s exactly", len(funcsInfo), functionName, strings.Join(names, ", "))
+	}
+	static := funcsInfo[0]
+
+	harness, err := Harness(filePath, static.Name, options.Sizes)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := copyPackage(filepath.Dir(filePath), harness)
+	if dir != "" {
+		defer os.RemoveAll(dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	command := exec.Command("go", "test", "-run", "^$", "-bench", "^BenchmarkFunalyserVerify$", "-benchmem",
+		"-benchtime", options.BenchTime.String(), "-timeout", options.Timeout.String(), "-count", strconv.Itoa(max(options.Count, 1)), ".")
+	command.Dir = dir
+	command.Env = append(os.Environ(), "GOWORK=off")
+	output, err := command.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("go test -bench failed: %v\n%s", err, output)
+	}
+
+	samples, err := ParseBenchmarks(output)
+	if err != nil {
+		return nil, err
+	}
+	var sizes []int
+	var nanoseconds, bytesAllocated []float64
+	for _, sample := range samples {
+		sizes = append(sizes, sample.N)
+		nanoseconds = append(nanoseconds, sample.NsPerOp)
+		bytesAllocated = append(bytesAllocated, sample.BytesPerOp)
+	}
+	measured := analyser.Complexity{Time: Fit(sizes, nanoseconds), Space: Fit(sizes, bytesAllocated)}
+	return &Result{
+		Function:    static.Name,
+		Static:      static.Complexity,
+		Measured:    measured,
+		Samples:     samples,
+		TimeAgrees:  measured.Time == static.Complexity.Time,
+		SpaceAgrees: measured.Space == static.Complexity.Space,
+	}, nil
+}
+
+// copyPackage copies the Go files of a package, without its tests, into a temporary module
+// along with the harness. That module requires the one the package belongs to, replaced by its
+// directory, so that the imports of the package resolve like they do in place
+func copyPackage(packageDir string, harness []byte) (string, error) {
+	packageDir, err := filepath.Abs(packageDir)
+	if err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp("", "funalyser-verify-")
+	if err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(packageDir)
+	if err != nil {
+		return dir, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		source, err := os.ReadFile(filepath.Join(packageDir, name))
+		if err != nil {
+			return dir, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), source, 0o644); err != nil {
+			return dir, err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, HarnessFile), harness, 0o644); err != nil {
+		return dir, err
+	}
+	return dir, copyModule(packageDir, dir)
+}
+
+// copyModule writes the go.mod of the temporary module, with the requirements of the module
+// the package belongs to when there is one
+func copyModule(packageDir string, dir string) error {
+	root := packageDir
+	for {
+		if _, err := os.Stat(filepath.Join(root, "go.mod")); err == nil {
+			break
+		}
+		parent := filepath.Dir(root)
+		if parent == root {
+			return goCommand(dir, "mod", "init", "funalyser.verify")
+		}
+		root = parent
+	}
+	for _, name := range []string{"go.mod", "go.sum"} {
+		content, err := os.ReadFile(filepath.Join(root, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o644); err != nil {
+			return err
+		}
+	}
+
+	output, err := exec.Command("go", "mod", "edit", "-json", filepath.Join(root, "go.mod")).Output()
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %v", filepath.Join(root, "go.mod"), err)
+	}
+	var module struct {
+		Module  struct{ Path string }
+		Replace []struct {
+			Old, New struct{ Path, Version string }
+		}
+	}
+	if err := json.Unmarshal(output, &module); err != nil {
+		return err
+	}
+	edits := []string{"mod", "edit", "-module", "funalyser.verify",
+		"-require", module.Module.Path + "@v0.0.0", "-replace", module.Module.Path + "=" + root}
+	// replacements by directories are relative to the module
+	for _, replace := range module.Replace {
+		if !strings.HasPrefix(replace.New.Path, ".") {
+			continue
+		}
+		old := replace.Old.Path
+		if replace.Old.Version != "" {
+			old += "@" + replace.Old.Version
+		}
+		edits = append(edits, "-replace", old+"="+filepath.Join(root, replace.New.Path))
+	}
+	return goCommand(dir, edits...)
+}
+
+func goCommand(dir string, args ...string) error {
+	command := exec.Command("go", args...)
+	command.Dir = dir
+	command.Env = append(os.Environ(), "GOWORK=off")
+	if output, err := command.CombinedOutput(); err != nil {
+		return fmt.Errorf("go %s failed: %v\n%s", args[0], err, output)
+	}
+	return nil
+}
+
+var benchmarkLine = regexp.MustCompile(`^BenchmarkFunalyserVerify/n=(\d+)(?:-\d+)?\s+\d+\s+([\d.]+) ns/op(?:\s+([\d.]+) B/op\s+([\d.]+) allocs/op)?`)
+
+// ParseBenchmarks reads the samples out of `go test -bench -benchmem` output, keeping the
+// fastest of the runs of every size
+func ParseBenchmarks(output []byte) ([]Sample, error) {
+	var samples []Sample
+	bySize := map[int]int{}
+	for _, line := range strings.Split(string(output), "\n") {
+		match := benchmarkLine.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		sample := Sample{}
+		sample.N, _ = strconv.Atoi(match[1])
+		sample.NsPerOp, _ = strconv.ParseFloat(match[2], 64)
+		if match[3] != "" {
+			sample.BytesPerOp, _ = strconv.ParseFloat(match[3], 64)
+			sample.AllocsPerOp, _ = strconv.ParseFloat(match[4], 64)
+		}
+		if i, ok := bySize[sample.N]; ok {
+			samples[i].NsPerOp = min(samples[i].NsPerOp, sample.NsPerOp)
+			samples[i].BytesPerOp = min(samples[i].BytesPerOp, sample.BytesPerOp)
+			samples[i].AllocsPerOp = min(samples[i].AllocsPerOp, sample.AllocsPerOp)
+			continue
+		}
+		bySize[sample.N] = len(samples)
+		samples = append(samples, sample)
+	}
+	if len(samples) == 0 {
+		return nil, errors.New("no benchmark results in the go test output")
+	}
+	return samples, nil
+}
+
+// Harness generates a benchmark calling the function with generated arguments of every size
+func Harness(filePath string, functionName string, sizes []int) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filePath, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	var decl *ast.FuncDecl
+	for _, declaration := range file.Decls {
+		if funcDecl, ok := declaration.(*ast.FuncDecl); ok && funcDecl.Name.Name == functionName && funcDecl.Recv == nil {
+			decl = funcDecl
+		}
+	}
+	if decl == nil {
+		return nil, fmt.Errorf("no function %s in %s, methods cannot be verified", functionName, filePath)
+	}
+	if decl.Type.TypeParams != nil {
+		return nil, fmt.Errorf("%s is generic and cannot be verified", functionName)
+	}
+
+	type argument struct{ Type, Generator string }
+	var arguments []argument
+	for _, field := range decl.Type.Params.List {
+		generator, err := generatorFor(field.Type)
+		if err != nil {
+			return nil, err
+		}
+		names := len(field.Names)
+		if names == 0 {
+			names = 1
+		}
+		for range names {
+			arguments = append(arguments, argument{typeString(field.Type), generator})
+		}
+	}
+
+	var source bytes.Buffer
+	err = harnessTemplate.Execute(&source, map[string]any{
+		"Package":   file.Name.Name,
+		"Function":  functionName,
+		"Arguments": arguments,
+		"Sizes":     sizes,
+		"HasResult": decl.Type.Results != nil && decl.Type.Results.NumFields() == 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(source.Bytes())
+}
+
+// generatorFor is the expression building an argument of size n for a parameter type
+func generatorFor(typeExpr ast.Expr) (string, error) {
+	switch expr := typeExpr.(type) {
+	case *ast.Ident:
+		if isNumeric(expr.Name) {
+			return expr.Name + "(n)", nil
+		}
+		switch expr.Name {
+		case "string":
+			return "funalyserString(n)", nil
+		case "bool":
+			return "true", nil
+		}
+	case *ast.ArrayType:
+		if element, ok := elementFor(expr.Elt); ok && expr.Len == nil {
+			return fmt.Sprintf("funalyserSlice(n, func(i int) %s { return %s })", typeString(expr.Elt), element), nil
+		}
+	case *ast.MapType:
+		key, keyOk := expr.Key.(*ast.Ident)
+		value, valueOk := elementFor(expr.Value)
+		if keyOk && valueOk && (key.Name == "int" || key.Name == "string") {
+			keyExpr := "i"
+			if key.Name == "string" {
+				keyExpr = "strconv.Itoa(i)"
+			}
+			return fmt.Sprintf("funalyserMap(n, func(i int) (%s, %s) { return %s, %s })", key.Name, typeString(expr.Value), keyExpr, value), nil
+		}
+	}
+	return "", fmt.Errorf("cannot generate inputs of type %s", typeString(typeExpr))
+}
+
+// elementFor is the expression building one random element of a slice or map value
+func elementFor(typeExpr ast.Expr) (string, bool) {
+	ident, ok := typeExpr.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	if isNumeric(ident.Name) {
+		return ident.Name + "(funalyserRandom.Intn(n + 1))", true
+	}
+	switch ident.Name {
+	case "string":
+		return "funalyserString(8)", true
+	case "bool":
+		return "funalyserRandom.Intn(2) == 0", true
+	}
+	return "", false
+}
+
+func isNumeric(typeName string) bool {
+	switch typeName {
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+		"float32", "float64", "byte", "rune":
+		return true
+	}
+	return false
+}
+
+func typeString(typeExpr ast.Expr) string {
+	var typeName bytes.Buffer
+	format.Node(&typeName, token.NewFileSet(), typeExpr)
+	return typeName.String()
+}
+
+var harnessTemplate = template.Must(template.New("harness").Parse(`// Code generated by funalyser verify. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"math/rand"
+	"os"
+	"strconv"
+	"testing"
+)
+
+var funalyserSink any
+
+// funalyserBatch is how many elements of inputs are generated at once, the timer is only stopped
+// for every batch so that stopping it does not hide the cost of fast functions
+const funalyserBatch = 1 << 16
+
+var funalyserRandom = rand.New(rand.NewSource(1))
+
+func funalyserString(n int) string {
+	letters := make([]byte, n)
+	for i := range letters {
+		letters[i] = byte('a' + funalyserRandom.Intn(26))
+	}
+	return string(letters)
+}
+
+func funalyserSlice[T any](n int, element func(i int) T) []T {
+	slice := make([]T, n)
+	for i := range slice {
+		slice[i] = element(i)
+	}
+	return slice
+}
+
+func funalyserMap[K comparable, V any](n int, entry func(i int) (K, V)) map[K]V {
+	m := make(map[K]V, n)
+	for i := 0; i < n; i++ {
+		key, value := entry(i)
+		m[key] = value
+	}
+	return m
+}
+
+func BenchmarkFunalyserVerify(b *testing.B) {
+	for _, n := range []int{ {{- range $i, $size := .Sizes}}{{if $i}}, {{end}}{{$size}}{{end -}} } {
+		b.Run("n="+strconv.Itoa(n), func(b *testing.B) {
+			// whatever the function prints would garble the benchmark results
+			stdout := os.Stdout
+			os.Stdout, _ = os.Open(os.DevNull)
+			defer func() { os.Stdout = stdout }()
+			type input struct {
+				{{- range $i, $argument := .Arguments}}
+				argument{{$i}} {{$argument.Type}}
+				{{- end}}
+			}
+			inputs := make([]input, min(b.N, max(funalyserBatch/(n+1), 1)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for done := 0; done < b.N; done += len(inputs) {
+				b.StopTimer()
+				inputs = inputs[:min(cap(inputs), b.N-done)]
+				for i := range inputs {
+					inputs[i] = input{ {{- range $i, $argument := .Arguments}}{{if $i}}, {{end}}{{$argument.Generator}}{{end -}} }
+				}
+				b.StartTimer()
+				for _, input := range inputs {
+					{{if .HasResult}}funalyserSink = {{end}}{{.Function}}({{range $i, $argument := .Arguments}}{{if $i}}, {{end}}input.argument{{$i}}{{end}})
+				}
+			}
+		})
+	}
+}
+`))